@@ -0,0 +1,820 @@
+package v2
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrArchiveTooLarge is returned when an archive entry or the whole archive exceeds the
+	// limits set with WithMaxEntrySize and WithMaxTotalSize.
+	ErrArchiveTooLarge = errors.New("lambda/v2: archive exceeds size limit")
+	// ErrUnsafePath is returned when extracting an entry whose path or link target would leave
+	// the destination directory.
+	ErrUnsafePath = errors.New("lambda/v2: unsafe archive path")
+
+	errEntryConsumed = errors.New("lambda/v2: archive entry body is no longer readable")
+)
+
+// WithMaxEntrySize limits the size of a single archive entry. The default is
+// DefaultDecompressLimit; n < 0 disables the limit.
+func WithMaxEntrySize(n int64) ChanOption {
+	return func(c *chanConfig) {
+		if c == nil {
+			return
+		}
+		c.maxEntrySize = n
+		c.needs(featArchive, "WithMaxEntrySize")
+	}
+}
+
+// WithMaxTotalSize limits the summed size of all entries of an archive (no limit by default).
+func WithMaxTotalSize(n int64) ChanOption {
+	return func(c *chanConfig) {
+		if c == nil {
+			return
+		}
+		c.maxTotalSize = n
+		c.needs(featArchive, "WithMaxTotalSize")
+	}
+}
+
+// ArchiveEntry is a file, directory or link in a tar or zip archive.
+// For hard links Linkname is set and Mode is a regular file mode.
+type ArchiveEntry struct {
+	Name     string // slash-separated path inside the archive
+	Size     int64
+	Mode     fs.FileMode
+	ModTime  time.Time
+	Linkname string // target of a symbolic or hard link
+
+	open func() (io.ReadCloser, error)
+	body *tarBody // set by ReadTar
+}
+
+// IsDir reports whether the entry is a directory.
+func (e ArchiveEntry) IsDir() bool { return e.Mode.IsDir() }
+
+func (e ArchiveEntry) isSymlink() bool  { return e.Mode&fs.ModeSymlink != 0 }
+func (e ArchiveEntry) isHardlink() bool { return e.Linkname != "" && e.Mode.IsRegular() }
+
+// Open opens the entry's body. Entries without a body read as empty.
+func (e ArchiveEntry) Open() ReadCloser {
+	if e.open == nil {
+		return ReadCloser{Ok[io.ReadCloser](io.NopCloser(bytes.NewReader(nil)))}
+	}
+	rc, err := e.open()
+	return ReadCloser{Wrap(rc, err)}
+}
+
+// Bytes reads the entry's body.
+func (e ArchiveEntry) Bytes() Bytes { return e.Open().Slurp() }
+
+// BytesEntry creates a regular file entry holding b, e.g. to feed WriteTar or WriteZip.
+func BytesEntry(name string, b []byte, mode fs.FileMode, modTime time.Time) ArchiveEntry {
+	return ArchiveEntry{
+		Name:    name,
+		Size:    int64(len(b)),
+		Mode:    mode.Perm(),
+		ModTime: modTime,
+		open:    func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(b)), nil },
+	}
+}
+
+// FileEntry creates an entry named name from the file, directory or symlink at path. Regular
+// files are opened lazily when the entry is written.
+func FileEntry(path, name string) Option[ArchiveEntry] {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return Err[ArchiveEntry](err)
+	}
+	e := ArchiveEntry{Name: name, Mode: fi.Mode(), ModTime: fi.ModTime()}
+	switch {
+	case fi.Mode()&fs.ModeSymlink != 0:
+		if e.Linkname, err = os.Readlink(path); err != nil {
+			return Err[ArchiveEntry](err)
+		}
+	case fi.Mode().IsRegular():
+		e.Size = fi.Size()
+		e.open = func() (io.ReadCloser, error) { return os.Open(path) }
+	case !fi.IsDir():
+		return Err[ArchiveEntry](fmt.Errorf("lambda/v2: %s: unsupported file type %v", path, fi.Mode().Type()))
+	}
+	return Ok(e)
+}
+
+// sizeLimits enforces WithMaxEntrySize and WithMaxTotalSize on declared entry sizes.
+type sizeLimits struct {
+	entry, total, seen int64
+}
+
+func newSizeLimits(cfg chanConfig) *sizeLimits {
+	l := &sizeLimits{entry: cfg.maxEntrySize, total: cfg.maxTotalSize}
+	if l.entry == 0 {
+		l.entry = DefaultDecompressLimit
+	}
+	return l
+}
+
+func (l *sizeLimits) check(name string, size int64) error {
+	if l.entry > 0 && size > l.entry {
+		return fmt.Errorf("%w: %s is %d bytes (entry limit %d)", ErrArchiveTooLarge, name, size, l.entry)
+	}
+	l.seen += size
+	if l.total > 0 && l.seen > l.total {
+		return fmt.Errorf("%w: %d bytes through %s (total limit %d)", ErrArchiveTooLarge, l.seen, name, l.total)
+	}
+	return nil
+}
+
+// TarEntries iterates the entries of a tar archive; gzip and bzip2 compressed archives are
+// detected automatically. Entries are read sequentially, so an entry's body is only readable
+// until the loop advances. Use ReadTar to process entries concurrently.
+func TarEntries(r io.Reader, opts ...ChanOption) iter.Seq2[ArchiveEntry, error] {
+	return func(yield func(ArchiveEntry, error) bool) {
+		if r == nil {
+			yield(ArchiveEntry{}, errNilReader)
+			return
+		}
+		cfg, err := chanCfg(opts, featArchive)
+		if err != nil {
+			yield(ArchiveEntry{}, err)
+			return
+		}
+		src, err := detectReader(r)
+		if err != nil {
+			yield(ArchiveEntry{}, err)
+			return
+		}
+		limits := newSizeLimits(cfg)
+		tr := tar.NewReader(src)
+		for {
+			hdr, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err == nil {
+				err = limits.check(hdr.Name, hdr.Size)
+			}
+			if err != nil {
+				yield(ArchiveEntry{}, err)
+				return
+			}
+			e := ArchiveEntry{
+				Name:     hdr.Name,
+				Size:     hdr.Size,
+				Mode:     hdr.FileInfo().Mode(),
+				ModTime:  hdr.ModTime,
+				Linkname: hdr.Linkname,
+			}
+			valid := new(atomic.Bool)
+			valid.Store(true)
+			if hdr.Typeflag == tar.TypeReg {
+				e.open = func() (io.ReadCloser, error) {
+					if !valid.Load() {
+						return nil, errEntryConsumed
+					}
+					return io.NopCloser(tr), nil
+				}
+			}
+			cont := yield(e, nil)
+			valid.Store(false)
+			if !cont {
+				return
+			}
+		}
+	}
+}
+
+// ReadTar emits the entries of a tar archive (gzip and bzip2 compression are detected).
+// Like TarEntries, the archive is read in order. A body opened before the next entry is received
+// is streamed from the archive, and ReadTar waits until it has been read to the end or closed
+// (Bytes and WriteTar do both) before reading on. Bodies that are not opened by then are
+// dropped: their entries can be skipped without opening them. Until a body is opened, ReadTar
+// spools it (in memory, then in a temporary file) so that it can read on. Use ReadZip to
+// process entries concurrently.
+func ReadTar(ctx context.Context, r io.Reader, opts ...ChanOption) (<-chan ArchiveEntry, <-chan error) {
+	return readArchive(ctx, "ReadTar", func() iter.Seq2[ArchiveEntry, error] {
+		return func(yield func(ArchiveEntry, error) bool) {
+			for e, err := range TarEntries(r, opts...) {
+				if err == nil && e.open != nil {
+					if e.Size == 0 {
+						e.open = nil // reads as empty
+					} else if e, err = e.spooled(); err != nil {
+						e = ArchiveEntry{}
+					}
+				}
+				if !yield(e, err) {
+					return
+				}
+			}
+		}
+	}, opts)
+}
+
+// tarSpoolMemory is how much of an unopened ReadTar body is spooled in memory before a
+// temporary file is used.
+const tarSpoolMemory = 64 << 10
+
+// spooled moves e's body (only readable during the TarEntries iteration) into a tarBody.
+func (e ArchiveEntry) spooled() (ArchiveEntry, error) {
+	rc, err := e.open()
+	if err != nil {
+		return e, err
+	}
+	e.body = &tarBody{src: rc, held: make(chan struct{})}
+	e.open = e.body.open
+	return e, nil
+}
+
+// tarBody is the body of a regular file emitted by ReadTar. Once opened, it is read from the
+// archive; until then readArchive spools it, so that it doesn't have to wait for a consumer that
+// never opens it.
+type tarBody struct {
+	mu     sync.Mutex
+	src    io.Reader // the archive, positioned in the body
+	mem    []byte    // spooled data, followed by the data spooled to file
+	file   *os.File
+	size   int64 // bytes spooled
+	off    int64 // read offset in the spooled data
+	eof    bool  // src is exhausted
+	opened bool
+	gone   bool // read to the end, closed or dropped: Open fails
+	closed bool
+
+	held chan struct{} // closed once readArchive may read on
+	once sync.Once
+}
+
+func (b *tarBody) release() { b.once.Do(func() { close(b.held) }) }
+
+// settle returns once readArchive may read on: the body was spooled to the end, or it was
+// opened and then read to the end or closed.
+func (b *tarBody) settle(ctx context.Context) error {
+	buf := make([]byte, 32<<10)
+	for {
+		b.mu.Lock()
+		if b.opened || b.eof {
+			b.mu.Unlock()
+			break
+		}
+		err := b.spool(buf)
+		b.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.held:
+		return nil
+	}
+}
+
+// spool copies the next chunk of the body from the archive. b.mu must be held.
+func (b *tarBody) spool(buf []byte) error {
+	n, err := b.src.Read(buf)
+	if n > 0 {
+		if b.file == nil && len(b.mem)+n <= tarSpoolMemory {
+			b.mem = append(b.mem, buf[:n]...)
+		} else {
+			if b.file == nil {
+				f, ferr := os.CreateTemp("", "lambda-tar-")
+				if ferr != nil {
+					return ferr
+				}
+				// Unlinked right away where the OS allows it, so that the data of a body
+				// nobody reads is freed with the file.
+				os.Remove(f.Name())
+				b.file = f
+			}
+			if _, werr := b.file.WriteAt(buf[:n], b.size-int64(len(b.mem))); werr != nil {
+				return werr
+			}
+		}
+		b.size += int64(n)
+	}
+	if errors.Is(err, io.EOF) {
+		b.eof = true
+		b.release()
+		return nil
+	}
+	return err
+}
+
+// drop makes an unopened body unreadable.
+func (b *tarBody) drop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.opened {
+		b.gone = true
+		b.cleanup()
+	}
+}
+
+// cleanup frees the spooled data. b.mu must be held.
+func (b *tarBody) cleanup() error {
+	b.mem = nil
+	if b.file == nil {
+		return nil
+	}
+	err := b.file.Close()
+	if rerr := os.Remove(b.file.Name()); rerr != nil && !errors.Is(rerr, fs.ErrNotExist) {
+		err = errors.Join(err, rerr)
+	}
+	b.file = nil
+	return err
+}
+
+func (b *tarBody) open() (io.ReadCloser, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.gone {
+		return nil, errEntryConsumed
+	}
+	b.opened = true
+	return b, nil
+}
+
+// Read reads the spooled data, then the rest of the body from the archive.
+func (b *tarBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.closed:
+		return 0, errEntryConsumed
+	case b.off < int64(len(b.mem)):
+		n := copy(p, b.mem[b.off:])
+		b.off += int64(n)
+		return n, nil
+	case b.off < b.size:
+		if rest := b.size - b.off; int64(len(p)) > rest {
+			p = p[:rest]
+		}
+		n, err := b.file.ReadAt(p, b.off-int64(len(b.mem)))
+		b.off += int64(n)
+		if errors.Is(err, io.EOF) && n > 0 {
+			err = nil
+		}
+		return n, err
+	case b.eof:
+		return 0, b.finish()
+	}
+	n, err := b.src.Read(p)
+	if errors.Is(err, io.EOF) {
+		b.eof = true
+		if ferr := b.finish(); n == 0 {
+			err = ferr
+		}
+	}
+	return n, err
+}
+
+// finish is called once the body was read to the end. It returns io.EOF unless freeing the
+// spooled data failed. b.mu must be held.
+func (b *tarBody) finish() error {
+	b.gone = true
+	b.release()
+	if err := b.cleanup(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+// Close releases the body; it can no longer be read.
+func (b *tarBody) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed, b.gone = true, true
+	b.release()
+	return b.cleanup()
+}
+
+// ZipEntries iterates the entries of a zip archive. Bodies are opened lazily and stay readable
+// as long as ra is, so entries may be processed in any order and concurrently.
+func ZipEntries(ra io.ReaderAt, size int64, opts ...ChanOption) iter.Seq2[ArchiveEntry, error] {
+	return func(yield func(ArchiveEntry, error) bool) {
+		if ra == nil {
+			yield(ArchiveEntry{}, errNilReader)
+			return
+		}
+		cfg, err := chanCfg(opts, featArchive)
+		if err != nil {
+			yield(ArchiveEntry{}, err)
+			return
+		}
+		zr, err := zip.NewReader(ra, size)
+		if err != nil {
+			yield(ArchiveEntry{}, err)
+			return
+		}
+		limits := newSizeLimits(cfg)
+		for _, f := range zr.File {
+			err := limits.check(f.Name, int64(min(f.UncompressedSize64, math.MaxInt64)))
+			if err != nil {
+				yield(ArchiveEntry{}, err)
+				return
+			}
+			e := ArchiveEntry{
+				Name:    f.Name,
+				Size:    int64(f.UncompressedSize64),
+				Mode:    f.Mode(),
+				ModTime: f.Modified,
+				open:    f.Open,
+			}
+			if e.isSymlink() {
+				// Zip stores the link target as the entry's body.
+				target, err := e.Bytes().Get()
+				if err != nil {
+					yield(ArchiveEntry{}, err)
+					return
+				}
+				e.Linkname, e.Size, e.open = string(target), 0, nil
+			}
+			if !yield(e, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ReadZip emits the entries of a zip archive. Bodies are opened lazily (see ZipEntries).
+func ReadZip(ctx context.Context, ra io.ReaderAt, size int64, opts ...ChanOption) (<-chan ArchiveEntry, <-chan error) {
+	return readArchive(ctx, "ReadZip", func() iter.Seq2[ArchiveEntry, error] {
+		return ZipEntries(ra, size, opts...)
+	}, opts)
+}
+
+func readArchive(ctx context.Context, name string, entries func() iter.Seq2[ArchiveEntry, error], opts []ChanOption) (<-chan ArchiveEntry, <-chan error) {
+	cfg, err := chanCfg(opts, featArchive)
+	if err != nil {
+		return closedErrStream[ArchiveEntry](err)
+	}
+	out := make(chan ArchiveEntry, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, name, errc)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		var (
+			bodies        []*tarBody // of the entries sent, from the oldest one not dropped
+			sent, dropped int
+		)
+		for e, err := range entries() {
+			if err != nil {
+				errc <- err
+				return
+			}
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case out <- e:
+			}
+			// A body is dropped, unless it was opened, once the next entry was received.
+			// Entries still buffered in out have not been received yet.
+			sent++
+			bodies = append(bodies, e.body)
+			for received := sent - len(out); dropped < received-1; dropped++ {
+				if bodies[0] != nil {
+					bodies[0].drop()
+				}
+				bodies = bodies[1:]
+			}
+			if e.body != nil {
+				if err := e.body.settle(ctx); err != nil {
+					errc <- err
+					return
+				}
+			}
+		}
+		errc <- nil
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+// writeEntries calls write for every entry from in and returns the number written.
+func writeEntries(ctx context.Context, in <-chan ArchiveEntry, write func(ArchiveEntry) error) Option[int] {
+	if in == nil {
+		return Err[int](errNilChan)
+	}
+	ctx = ensureCtx(ctx)
+	n := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return Err[int](ctx.Err())
+		case e, ok := <-in:
+			if !ok {
+				return Ok(n)
+			}
+			if err := write(e); err != nil {
+				return Err[int](fmt.Errorf("lambda/v2: %s: %w", e.Name, err))
+			}
+			n++
+		}
+	}
+}
+
+// copyBody copies the entry's body to w and closes it.
+func (e ArchiveEntry) copyBody(w io.Writer) error {
+	rc, err := e.Open().Get()
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, rc)
+	return errors.Join(err, rc.Close())
+}
+
+// WriteTar writes every entry from in to a tar archive on w and returns the number of entries.
+// Permissions, modification times and links are preserved.
+func WriteTar(ctx context.Context, w io.Writer, in <-chan ArchiveEntry) Option[int] {
+	if w == nil {
+		return Err[int](errNilWriter)
+	}
+	tw := tar.NewWriter(w)
+	n := writeEntries(ctx, in, func(e ArchiveEntry) error {
+		hdr := &tar.Header{
+			Name:     e.Name,
+			Mode:     int64(e.Mode.Perm()),
+			ModTime:  e.ModTime,
+			Linkname: e.Linkname,
+		}
+		switch {
+		case e.IsDir():
+			hdr.Typeflag = tar.TypeDir
+			if !strings.HasSuffix(hdr.Name, "/") {
+				hdr.Name += "/"
+			}
+		case e.isSymlink():
+			hdr.Typeflag = tar.TypeSymlink
+		case e.isHardlink():
+			hdr.Typeflag = tar.TypeLink
+		default:
+			hdr.Typeflag, hdr.Size = tar.TypeReg, e.Size
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil
+		}
+		return e.copyBody(tw)
+	})
+	if err := tw.Close(); err != nil && n.err == nil {
+		return Err[int](err)
+	}
+	return n
+}
+
+// WriteTarGz is WriteTar with gzip compression at the given level.
+func WriteTarGz(ctx context.Context, w io.Writer, in <-chan ArchiveEntry, level int) Option[int] {
+	if w == nil {
+		return Err[int](errNilWriter)
+	}
+	zw, err := gzip.NewWriterLevel(w, level)
+	if err != nil {
+		return Err[int](err)
+	}
+	n := WriteTar(ctx, zw, in)
+	if err := zw.Close(); err != nil && n.err == nil {
+		return Err[int](err)
+	}
+	return n
+}
+
+// WriteZip writes every entry from in to a zip archive on w and returns the number of entries.
+// Files are deflated; permissions, modification times and symlinks are preserved. Zip has no
+// hard links, so they are rejected.
+func WriteZip(ctx context.Context, w io.Writer, in <-chan ArchiveEntry) Option[int] {
+	if w == nil {
+		return Err[int](errNilWriter)
+	}
+	zw := zip.NewWriter(w)
+	n := writeEntries(ctx, in, func(e ArchiveEntry) error {
+		if e.isHardlink() {
+			return errors.New("zip archives cannot hold hard links")
+		}
+		fh := &zip.FileHeader{Name: e.Name, Method: zip.Deflate, Modified: e.ModTime}
+		fh.SetMode(e.Mode)
+		if e.IsDir() {
+			fh.Method = zip.Store
+			if !strings.HasSuffix(fh.Name, "/") {
+				fh.Name += "/"
+			}
+		}
+		fw, err := zw.CreateHeader(fh)
+		if err != nil {
+			return err
+		}
+		switch {
+		case e.IsDir():
+			return nil
+		case e.isSymlink():
+			_, err = io.WriteString(fw, e.Linkname)
+			return err
+		default:
+			return e.copyBody(fw)
+		}
+	})
+	if err := zw.Close(); err != nil && n.err == nil {
+		return Err[int](err)
+	}
+	return n
+}
+
+// ExtractTar extracts a tar archive (gzip and bzip2 compression are detected) below dir and
+// returns the number of entries extracted. See extract for the safety rules.
+func ExtractTar(ctx context.Context, r io.Reader, dir string, opts ...ChanOption) Option[int] {
+	return extract(ctx, TarEntries(r, opts...), dir)
+}
+
+// ExtractZip extracts a zip archive below dir and returns the number of entries extracted.
+func ExtractZip(ctx context.Context, ra io.ReaderAt, size int64, dir string, opts ...ChanOption) Option[int] {
+	return extract(ctx, ZipEntries(ra, size, opts...), dir)
+}
+
+// extract writes entries below dir. Entry paths must be local (no absolute paths or ".."
+// escapes), symlink targets must be relative and may not contain "..", and files are created
+// through an os.Root. Links and directory metadata are set by path, so those paths may not
+// pass through a symlink (e.g. one already present in dir). Nothing is ever written outside dir. Permission bits and modification
+// times are restored; other file types (devices, FIFOs) are skipped.
+func extract(ctx context.Context, entries iter.Seq2[ArchiveEntry, error], dir string) Option[int] {
+	ctx = ensureCtx(ctx)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Err[int](err)
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return Err[int](err)
+	}
+	defer root.Close()
+
+	// Directory modes and times are applied last, so read-only directories can be filled.
+	type dirMeta struct {
+		path  string
+		mode  fs.FileMode
+		mtime time.Time
+	}
+	var dirs []dirMeta
+	n := 0
+	for e, err := range entries {
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			return Err[int](err)
+		}
+		rel := filepath.FromSlash(strings.TrimSuffix(e.Name, "/"))
+		if rel == "" || rel == "." {
+			continue
+		}
+		if !filepath.IsLocal(rel) {
+			return Err[int](fmt.Errorf("%w: %s", ErrUnsafePath, e.Name))
+		}
+		rel = filepath.Clean(rel)
+		target := filepath.Join(dir, rel)
+
+		switch {
+		case e.IsDir():
+			if err := mkdirAllRoot(root, rel); err != nil {
+				return Err[int](err)
+			}
+			// Modes and times are set by path, so the directory must not be a symlink.
+			if err := checkRealDirs(root, rel); err != nil {
+				return Err[int](err)
+			}
+			dirs = append(dirs, dirMeta{target, e.Mode.Perm(), e.ModTime})
+		case e.isSymlink():
+			link := filepath.FromSlash(e.Linkname)
+			if filepath.IsAbs(link) || hasDotDot(link) {
+				return Err[int](fmt.Errorf("%w: %s -> %s", ErrUnsafePath, e.Name, e.Linkname))
+			}
+			if err := mkdirAllRoot(root, filepath.Dir(rel)); err != nil {
+				return Err[int](err)
+			}
+			if err := checkRealDirs(root, filepath.Dir(rel)); err != nil {
+				return Err[int](err)
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return Err[int](err)
+			}
+		case e.isHardlink():
+			old := filepath.FromSlash(e.Linkname)
+			if !filepath.IsLocal(old) {
+				return Err[int](fmt.Errorf("%w: %s -> %s", ErrUnsafePath, e.Name, e.Linkname))
+			}
+			if fi, err := root.Lstat(old); err != nil {
+				return Err[int](err)
+			} else if !fi.Mode().IsRegular() {
+				return Err[int](fmt.Errorf("%w: %s -> %s", ErrUnsafePath, e.Name, e.Linkname))
+			}
+			if err := mkdirAllRoot(root, filepath.Dir(rel)); err != nil {
+				return Err[int](err)
+			}
+			if err := errors.Join(checkRealDirs(root, filepath.Dir(old)), checkRealDirs(root, filepath.Dir(rel))); err != nil {
+				return Err[int](err)
+			}
+			if err := os.Link(filepath.Join(dir, old), target); err != nil {
+				return Err[int](err)
+			}
+		case e.Mode.IsRegular():
+			if err := mkdirAllRoot(root, filepath.Dir(rel)); err != nil {
+				return Err[int](err)
+			}
+			if err := extractFile(root, rel, e); err != nil {
+				return Err[int](fmt.Errorf("lambda/v2: %s: %w", e.Name, err))
+			}
+			if !e.ModTime.IsZero() {
+				if err := os.Chtimes(target, e.ModTime, e.ModTime); err != nil {
+					return Err[int](err)
+				}
+			}
+		default:
+			continue
+		}
+		n++
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		d := dirs[i]
+		if err := os.Chmod(d.path, d.mode); err != nil {
+			return Err[int](err)
+		}
+		if !d.mtime.IsZero() {
+			if err := os.Chtimes(d.path, d.mtime, d.mtime); err != nil {
+				return Err[int](err)
+			}
+		}
+	}
+	return Ok(n)
+}
+
+func extractFile(root *os.Root, rel string, e ArchiveEntry) error {
+	f, err := root.OpenFile(rel, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	err = e.copyBody(f)
+	if err == nil {
+		err = f.Chmod(e.Mode.Perm())
+	}
+	return errors.Join(err, f.Close())
+}
+
+// mkdirAllRoot creates rel and its parents inside root.
+func mkdirAllRoot(root *os.Root, rel string) error {
+	if rel == "." || rel == "" {
+		return nil
+	}
+	if err := mkdirAllRoot(root, filepath.Dir(rel)); err != nil {
+		return err
+	}
+	if err := root.Mkdir(rel, 0o755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	return nil
+}
+
+// checkRealDirs fails with ErrUnsafePath unless rel and each of its parents inside root is a
+// directory rather than a symlink, so that a path-based operation on rel stays inside root.
+func checkRealDirs(root *os.Root, rel string) error {
+	for p := rel; p != "." && p != ""; p = filepath.Dir(p) {
+		fi, err := root.Lstat(p)
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			return fmt.Errorf("%w: %s is not a directory", ErrUnsafePath, p)
+		}
+	}
+	return nil
+}
+
+func hasDotDot(path string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if part == ".." {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,333 @@
+package v2
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+)
+
+var archiveTime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func archiveFixture() []ArchiveEntry {
+	return []ArchiveEntry{
+		{Name: "docs", Mode: fs.ModeDir | 0o755, ModTime: archiveTime},
+		BytesEntry("docs/readme.md", []byte("# hello\n"), 0o644, archiveTime),
+		BytesEntry("bin/run.sh", []byte("#!/bin/sh\necho hi\n"), 0o750, archiveTime),
+		{Name: "latest", Mode: fs.ModeSymlink | 0o777, Linkname: "docs/readme.md", ModTime: archiveTime},
+	}
+}
+
+func feedEntries(t *testing.T, es []ArchiveEntry) <-chan ArchiveEntry {
+	t.Helper()
+	ch, _ := FromSlice(context.Background(), es)
+	return ch
+}
+
+func TestTar_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if n := WriteTarGz(context.Background(), &buf, feedEntries(t, archiveFixture()), gzip.BestSpeed).Must(); n != 4 {
+		t.Fatalf("wrote %d entries", n)
+	}
+
+	out, errc := ReadTar(context.Background(), &buf, WithBuffer(4))
+	var entries []ArchiveEntry
+	bodies := map[string]string{}
+	for e := range out {
+		if e.Mode.IsRegular() {
+			bodies[e.Name] = string(e.Bytes().Must())
+		}
+		entries = append(entries, e)
+	}
+	mustErr(t, <-errc)
+	if len(entries) != 4 {
+		t.Fatalf("read %d entries", len(entries))
+	}
+	if got := bodies["bin/run.sh"]; got != "#!/bin/sh\necho hi\n" {
+		t.Fatalf("body %q", got)
+	}
+	// Bodies are streamed, so they cannot be read again later.
+	if _, err := entries[2].Bytes().Get(); !errors.Is(err, errEntryConsumed) {
+		t.Fatalf("err = %v", err)
+	}
+	if e := entries[2]; e.Mode.Perm() != 0o750 || !e.ModTime.Equal(archiveTime) {
+		t.Fatalf("entry %+v", e)
+	}
+	if !entries[0].IsDir() || entries[3].Linkname != "docs/readme.md" {
+		t.Fatalf("entries %+v", entries)
+	}
+}
+
+func TestReadTar_SkippedAndLateBodies(t *testing.T) {
+	// Not parallel: counts goroutines.
+	before := runtime.NumGoroutine()
+
+	ctx := context.Background()
+	big := bytes.Repeat([]byte("0123456789"), 20_000) // spooled to a temporary file
+	fixture := append(archiveFixture(),
+		BytesEntry("big.bin", big, 0o644, archiveTime),
+		BytesEntry("empty", nil, 0o644, archiveTime))
+	var buf bytes.Buffer
+	WriteTar(ctx, &buf, feedEntries(t, fixture)).Must()
+	raw := buf.Bytes()
+
+	// Dropped entries don't have to be opened.
+	out, errc := ReadTar(ctx, bytes.NewReader(raw))
+	other, ferrc := FilterChan(ctx, out, func(e ArchiveEntry) bool { return !e.Mode.IsRegular() })
+	if got := Collect(ctx, other).Must(); len(got) != 2 {
+		t.Fatalf("got %d entries", len(got))
+	}
+	mustErr(t, JoinErr(errc, ferrc))
+
+	// A body opened before the next entry is received is complete, however late it is opened.
+	out, errc = ReadTar(ctx, bytes.NewReader(raw))
+	var entries []ArchiveEntry
+	for e := range out {
+		if e.Name == "big.bin" {
+			time.Sleep(20 * time.Millisecond) // let ReadTar spool the body
+			if b := e.Bytes().Must(); !bytes.Equal(b, big) {
+				t.Fatalf("big.bin: got %d bytes", len(b))
+			}
+		}
+		entries = append(entries, e)
+	}
+	mustErr(t, <-errc)
+	if _, err := entries[1].Bytes().Get(); !errors.Is(err, errEntryConsumed) {
+		t.Fatalf("unopened body: err = %v", err)
+	}
+	if b, err := entries[5].Bytes().Get(); err != nil || len(b) != 0 {
+		t.Fatalf("empty body: %q, %v", b, err)
+	}
+
+	// Cancelling stops a reader waiting for an opened body.
+	cctx, cancel := context.WithCancel(ctx)
+	out, errc = ReadTar(cctx, bytes.NewReader(raw))
+	<-out // docs/
+	rc := (<-out).Open().Must()
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(rc, head); err != nil || string(head) != "# " {
+		t.Fatalf("head=%q err=%v", head, err)
+	}
+	cancel()
+	Drain(nil, out)
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v", err)
+	}
+	rc.Close()
+	waitGoroutines(t, before)
+}
+
+func TestTarEntries_BodyOnlyValidDuringIteration(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	WriteTar(context.Background(), &buf, feedEntries(t, archiveFixture())).Must()
+	var kept []ArchiveEntry
+	for e, err := range TarEntries(&buf) {
+		mustErr(t, err)
+		if e.Mode.IsRegular() {
+			if len(e.Bytes().Must()) != int(e.Size) {
+				t.Fatalf("short body for %s", e.Name)
+			}
+		}
+		kept = append(kept, e)
+	}
+	if _, err := kept[1].Bytes().Get(); !errors.Is(err, errEntryConsumed) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestExtractTar_PreservesMetadata(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	WriteTarGz(context.Background(), &buf, feedEntries(t, archiveFixture()), gzip.DefaultCompression).Must()
+	dir := t.TempDir()
+	if n := ExtractTar(context.Background(), &buf, dir).Must(); n != 4 {
+		t.Fatalf("extracted %d entries", n)
+	}
+
+	fi, err := os.Stat(filepath.Join(dir, "bin", "run.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o750 || !fi.ModTime().Equal(archiveTime) {
+		t.Fatalf("mode=%v mtime=%v", fi.Mode(), fi.ModTime())
+	}
+	if di, _ := os.Stat(filepath.Join(dir, "docs")); !di.ModTime().Equal(archiveTime) {
+		t.Fatalf("dir mtime = %v", di.ModTime())
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "latest"))
+	if err != nil || string(b) != "# hello\n" {
+		t.Fatalf("symlink: %q %v", b, err)
+	}
+}
+
+func maliciousTar(t *testing.T, hdr *tar.Header) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	body := []byte("pwned")
+	if hdr.Typeflag == tar.TypeReg {
+		hdr.Size = int64(len(body))
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		t.Fatal(err)
+	}
+	if hdr.Typeflag == tar.TypeReg {
+		tw.Write(body)
+	}
+	tw.Close()
+	return &buf
+}
+
+func TestExtract_RejectsTraversal(t *testing.T) {
+	t.Parallel()
+
+	headers := []*tar.Header{
+		{Name: "../evil", Typeflag: tar.TypeReg, Mode: 0o644},
+		{Name: "/etc/evil", Typeflag: tar.TypeReg, Mode: 0o644},
+		{Name: "a/../../evil", Typeflag: tar.TypeReg, Mode: 0o644},
+		{Name: "up", Typeflag: tar.TypeSymlink, Linkname: "../outside"},
+		{Name: "abs", Typeflag: tar.TypeSymlink, Linkname: "/etc"},
+		{Name: "hard", Typeflag: tar.TypeLink, Linkname: "../../etc/passwd"},
+	}
+	for _, hdr := range headers {
+		parent := t.TempDir()
+		dir := filepath.Join(parent, "out")
+		_, err := ExtractTar(context.Background(), maliciousTar(t, hdr), dir).Get()
+		if !errors.Is(err, ErrUnsafePath) {
+			t.Errorf("%s: err = %v", hdr.Name, err)
+		}
+		if entries, _ := os.ReadDir(parent); len(entries) != 1 {
+			t.Errorf("%s: wrote outside: %v", hdr.Name, entries)
+		}
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("../../zip-slip")
+	w.Write([]byte("pwned"))
+	zw.Close()
+	r := bytes.NewReader(buf.Bytes())
+	if _, err := ExtractZip(context.Background(), r, r.Size(), t.TempDir()).Get(); !errors.Is(err, ErrUnsafePath) {
+		t.Fatalf("zip err = %v", err)
+	}
+}
+
+func TestExtract_LinksDoNotFollowExistingSymlinks(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string][]*tar.Header{
+		"symlink":  {{Name: "evil/x", Typeflag: tar.TypeSymlink, Linkname: "y"}},
+		"hardlink": {{Name: "f", Typeflag: tar.TypeReg, Mode: 0o644}, {Name: "evil/h", Typeflag: tar.TypeLink, Linkname: "f"}},
+		"dir":      {{Name: "evil/", Typeflag: tar.TypeDir, Mode: 0o777}},
+	}
+	for name, hdrs := range cases {
+		outside := t.TempDir()
+		if err := os.Chmod(outside, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		dir := t.TempDir()
+		if err := os.Symlink(outside, filepath.Join(dir, "evil")); err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, hdr := range hdrs {
+			tw.WriteHeader(hdr)
+		}
+		tw.Close()
+
+		_, err := ExtractTar(context.Background(), &buf, dir).Get()
+		if !errors.Is(err, ErrUnsafePath) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+		if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+			t.Errorf("%s: wrote outside: %v", name, entries)
+		}
+		if fi, _ := os.Stat(outside); fi.Mode().Perm() != 0o700 {
+			t.Errorf("%s: outside mode changed to %v", name, fi.Mode())
+		}
+	}
+}
+
+func TestArchive_SizeLimits(t *testing.T) {
+	t.Parallel()
+
+	big := []ArchiveEntry{
+		BytesEntry("a", make([]byte, 60), 0o644, archiveTime),
+		BytesEntry("b", make([]byte, 60), 0o644, archiveTime),
+	}
+	var buf bytes.Buffer
+	WriteTar(context.Background(), &buf, feedEntries(t, big)).Must()
+	tarBytes := buf.Bytes()
+
+	_, errc := ReadTar(context.Background(), bytes.NewReader(tarBytes), WithMaxEntrySize(50))
+	if err := <-errc; !errors.Is(err, ErrArchiveTooLarge) {
+		t.Fatalf("entry limit: %v", err)
+	}
+	out, errc := ReadTar(context.Background(), bytes.NewReader(tarBytes), WithMaxTotalSize(100))
+	for e := range out {
+		e.Open().Close()
+	}
+	if err := <-errc; !errors.Is(err, ErrArchiveTooLarge) {
+		t.Fatalf("total limit: %v", err)
+	}
+	if _, err := ExtractTar(context.Background(), bytes.NewReader(tarBytes), t.TempDir(), WithMaxTotalSize(100)).Get(); !errors.Is(err, ErrArchiveTooLarge) {
+		t.Fatalf("extract limit: %v", err)
+	}
+	if _, errc := ReadLines(context.Background(), bytes.NewReader(tarBytes), WithMaxEntrySize(50)); !errors.Is(<-errc, errUnsupportedOption) {
+		t.Fatal("expected errUnsupportedOption")
+	}
+}
+
+func TestZip_RoundTripConcurrent(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if n := WriteZip(context.Background(), &buf, feedEntries(t, archiveFixture())).Must(); n != 4 {
+		t.Fatalf("wrote %d entries", n)
+	}
+	r := bytes.NewReader(buf.Bytes())
+
+	entries, errc := ReadZip(context.Background(), r, r.Size())
+	files, ferrc := FilterChan(context.Background(), entries, func(e ArchiveEntry) bool { return e.Mode.IsRegular() })
+	sums, serrc := ParTryChan(context.Background(), files, func(e ArchiveEntry) (string, error) {
+		return e.Bytes().SHA256().Hex().Get()
+	}, WithConcurrency(2))
+	got := Collect(nil, sums).Must()
+	mustErr(t, JoinErr(errc, ferrc, serrc))
+	sort.Strings(got)
+	want := []string{
+		BytesOf([]byte("# hello\n")).SHA256().Hex().Must(),
+		BytesOf([]byte("#!/bin/sh\necho hi\n")).SHA256().Hex().Must(),
+	}
+	sort.Strings(want)
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("got %v", got)
+	}
+
+	dir := t.TempDir()
+	if n := ExtractZip(context.Background(), r, r.Size(), dir).Must(); n != 4 {
+		t.Fatalf("extracted %d entries", n)
+	}
+	if target, err := os.Readlink(filepath.Join(dir, "latest")); err != nil || target != "docs/readme.md" {
+		t.Fatalf("symlink %q %v", target, err)
+	}
+	if fi, _ := os.Stat(filepath.Join(dir, "bin", "run.sh")); fi.Mode().Perm() != 0o750 {
+		t.Fatalf("mode = %v", fi.Mode())
+	}
+}
@@ -0,0 +1,202 @@
+package v2
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// ErrChecksumMismatch is returned when an atomic write is aborted because the written data
+// does not match the checksum set with WithExpectedSHA256.
+var ErrChecksumMismatch = errors.New("lambda/v2: checksum mismatch")
+
+type atomicConfig struct {
+	perm   fs.FileMode
+	backup bool
+	sum    *[sha256.Size]byte
+}
+
+// AtomicOption configures AtomicCreate and Bytes.WriteFileAtomic.
+type AtomicOption func(*atomicConfig)
+
+// WithPerm sets the permissions of the file written by AtomicCreate. By default an existing
+// file keeps its permissions and a new one gets 0644.
+func WithPerm(perm fs.FileMode) AtomicOption {
+	return func(c *atomicConfig) {
+		c.perm = perm
+	}
+}
+
+// WithBackup keeps the previous contents of the target as path + ".bak".
+func WithBackup() AtomicOption {
+	return func(c *atomicConfig) {
+		c.backup = true
+	}
+}
+
+// WithExpectedSHA256 aborts the write with ErrChecksumMismatch unless the written data has
+// the given SHA256 sum; the target is left untouched.
+func WithExpectedSHA256(sum [sha256.Size]byte) AtomicOption {
+	return func(c *atomicConfig) {
+		c.sum = &sum
+	}
+}
+
+// AtomicFile is the io.WriteCloser returned by AtomicCreate. Data is written to a temporary file
+// in the target's directory; Close syncs it, renames it over the target and syncs the directory,
+// so readers see either the old or the new contents, even after a crash.
+type AtomicFile struct {
+	path string
+	cfg  atomicConfig
+	tmp  *os.File
+	hash hash.Hash
+	err  error // first write error; Close then aborts
+
+	once     sync.Once
+	closeErr error
+}
+
+// AtomicCreate starts an atomic write of path. Nothing is visible at path until Close succeeds;
+// call Abort (or let a write fail) to discard the data. WriteCloser.Close aborts as well if any
+// step of the chain failed.
+func AtomicCreate(path string, opts ...AtomicOption) WriteCloser {
+	f, err := atomicCreate(path, opts)
+	if err != nil {
+		return WriteCloser{Err[io.WriteCloser](err)}
+	}
+	return WriteCloser{Ok[io.WriteCloser](f)}
+}
+
+func atomicCreate(path string, opts []AtomicOption) (*AtomicFile, error) {
+	var cfg atomicConfig
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	if cfg.perm == 0 {
+		cfg.perm = 0o644
+		if fi, err := os.Stat(path); err == nil {
+			cfg.perm = fi.Mode().Perm()
+		}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return nil, err
+	}
+	return &AtomicFile{path: path, cfg: cfg, tmp: tmp, hash: sha256.New()}, nil
+}
+
+// Write implements io.Writer.
+func (f *AtomicFile) Write(p []byte) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	n, err := f.tmp.Write(p)
+	f.hash.Write(p[:n])
+	if err != nil {
+		f.err = err
+	}
+	return n, err
+}
+
+// Abort discards the temporary file and leaves the target untouched.
+func (f *AtomicFile) Abort() error {
+	f.once.Do(func() { f.closeErr = f.discard() })
+	return f.closeErr
+}
+
+func (f *AtomicFile) discard() error {
+	return errors.Join(f.tmp.Close(), os.Remove(f.tmp.Name()))
+}
+
+// Close commits the write. If a write failed or the checksum does not match, the temporary file
+// is discarded and the error returned. Calling Close again returns the same result.
+func (f *AtomicFile) Close() error {
+	f.once.Do(func() { f.closeErr = f.commit() })
+	return f.closeErr
+}
+
+func (f *AtomicFile) commit() error {
+	if f.err != nil {
+		return errors.Join(f.err, f.discard())
+	}
+	if f.cfg.sum != nil {
+		var got [sha256.Size]byte
+		copy(got[:], f.hash.Sum(nil))
+		if got != *f.cfg.sum {
+			return errors.Join(fmt.Errorf("%w: %s: got %x", ErrChecksumMismatch, f.path, got), f.discard())
+		}
+	}
+	if err := errors.Join(f.tmp.Chmod(f.cfg.perm), f.tmp.Sync()); err != nil {
+		return errors.Join(err, f.discard())
+	}
+	if err := f.tmp.Close(); err != nil {
+		return errors.Join(err, os.Remove(f.tmp.Name()))
+	}
+	if f.cfg.backup {
+		if err := backupFile(f.path); err != nil {
+			return errors.Join(err, os.Remove(f.tmp.Name()))
+		}
+	}
+	if err := os.Rename(f.tmp.Name(), f.path); err != nil {
+		return errors.Join(err, os.Remove(f.tmp.Name()))
+	}
+	return syncDir(filepath.Dir(f.path))
+}
+
+// backupFile preserves the current contents of path as path + ".bak" (a hard link when possible).
+func backupFile(path string) error {
+	bak := path + ".bak"
+	if err := os.Remove(bak); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	err := os.Link(path, bak)
+	if err == nil || errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	// Hard links are unsupported here: fall back to a copy.
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(bak, b, fi.Mode().Perm())
+}
+
+// syncDir flushes a directory entry change (e.g. a rename) to disk.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil // directories cannot be opened for syncing
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	return errors.Join(d.Sync(), d.Close())
+}
+
+// WriteFileAtomic writes the bytes to path atomically (see AtomicCreate) with the given
+// permissions. The bytes are passed through unchanged.
+func (b Bytes) WriteFileAtomic(path string, perm fs.FileMode, opts ...AtomicOption) Bytes {
+	if b.err != nil {
+		return b
+	}
+	f, err := atomicCreate(path, append([]AtomicOption{WithPerm(perm)}, opts...))
+	if err != nil {
+		return Bytes{Err[[]byte](err)}
+	}
+	_, err = io.Copy(f, bytes.NewReader(b.v))
+	return Bytes{Wrap(b.v, errors.Join(err, f.Close()))}
+}
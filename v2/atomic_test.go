@@ -0,0 +1,131 @@
+package v2
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// dirNames lists the names in dir.
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestWriteFileAtomic_ReplacesWithBackup(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"v":1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	StrOf(`{"v":2}`).Bytes().WriteFileAtomic(path, 0o640, WithBackup()).Must()
+
+	if b, _ := os.ReadFile(path); string(b) != `{"v":2}` {
+		t.Fatalf("content %q", b)
+	}
+	if b, _ := os.ReadFile(path + ".bak"); string(b) != `{"v":1}` {
+		t.Fatalf("backup %q", b)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0o640 {
+		t.Fatalf("mode = %v", fi.Mode())
+	}
+	if names := dirNames(t, dir); len(names) != 2 {
+		t.Fatalf("left behind: %v", names)
+	}
+}
+
+func TestAtomicCreate_ChecksumMismatchKeepsTarget(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.bin")
+	os.WriteFile(path, []byte("old"), 0o644)
+
+	wc := AtomicCreate(path, WithExpectedSHA256(sha256.Sum256([]byte("expected")))).Must()
+	io.WriteString(wc, "something else")
+	if err := wc.Close(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("err = %v", err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "old" {
+		t.Fatalf("target changed: %q", b)
+	}
+
+	wc = AtomicCreate(path, WithExpectedSHA256(sha256.Sum256([]byte("expected")))).Must()
+	io.WriteString(wc, "expected")
+	mustErr(t, wc.Close())
+	mustErr(t, wc.Close())
+	if b, _ := os.ReadFile(path); string(b) != "expected" {
+		t.Fatalf("content %q", b)
+	}
+	if names := dirNames(t, dir); len(names) != 1 {
+		t.Fatalf("left behind: %v", names)
+	}
+}
+
+func TestAtomicCreate_FailedChainKeepsTarget(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	os.WriteFile(path, []byte(`{"ok":true}`), 0o644)
+
+	sentinel := errors.New("source failed")
+	src := io.MultiReader(strings.NewReader(`{"partial":"x`), iotest.ErrReader(sentinel))
+	if _, err := AtomicCreate(path).Copy(Read(src)).Close().Get(); !errors.Is(err, sentinel) {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err := AtomicCreate(path).Write(Bytes{Err[[]byte](sentinel)}).Close().Get(); !errors.Is(err, sentinel) {
+		t.Fatalf("err = %v", err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != `{"ok":true}` {
+		t.Fatalf("target changed: %q", b)
+	}
+	if names := dirNames(t, dir); len(names) != 1 {
+		t.Fatalf("left behind: %v", names)
+	}
+}
+
+func TestAtomicCreate_AbortAndDefaults(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "script.sh")
+	os.WriteFile(path, []byte("v1"), 0o755)
+
+	f := AtomicCreate(path).Must().(*AtomicFile)
+	io.WriteString(f, "half-written")
+	mustErr(t, f.Abort())
+	if b, _ := os.ReadFile(path); string(b) != "v1" {
+		t.Fatalf("target changed: %q", b)
+	}
+
+	wc := AtomicCreate(path).Must()
+	io.WriteString(wc, "v2")
+	mustErr(t, wc.Close())
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0o755 {
+		t.Fatalf("existing mode not kept: %v", fi.Mode())
+	}
+	if names := dirNames(t, dir); len(names) != 1 {
+		t.Fatalf("left behind: %v", names)
+	}
+
+	if _, err := AtomicCreate(filepath.Join(dir, "missing", "x")).Get(); err == nil {
+		t.Fatal("expected error for missing directory")
+	}
+}
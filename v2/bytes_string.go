@@ -2,7 +2,6 @@ package v2
 
 import (
 	"bytes"
-	"errors"
 	"io"
 	"strings"
 )
@@ -76,6 +75,15 @@ func (r Reader) ReadAll() Bytes {
 	return ReadAll(r.v)
 }
 
+// ReadAllLimit reads all content from the contained io.Reader, failing with ErrTooLarge if it is
+// longer than limit bytes.
+func (r Reader) ReadAllLimit(limit int64) Bytes {
+	if r.err != nil {
+		return Bytes{Err[[]byte](r.err)}
+	}
+	return ReadAllLimit(r.v, limit)
+}
+
 // Slurp reads all content from the contained io.ReadCloser and closes it.
 func (r ReadCloser) Slurp() Bytes {
 	if r.err != nil {
@@ -84,13 +92,22 @@ func (r ReadCloser) Slurp() Bytes {
 	return Slurp(r.v)
 }
 
+// SlurpLimit reads all content from the contained io.ReadCloser and closes it, failing with
+// ErrTooLarge if it is longer than limit bytes.
+func (r ReadCloser) SlurpLimit(limit int64) Bytes {
+	if r.err != nil {
+		return Bytes{Err[[]byte](r.err)}
+	}
+	return SlurpLimit(r.v, limit)
+}
+
 // WriteTo implements io.WriterTo for Bytes.
 func (b Bytes) WriteTo(w io.Writer) (int64, error) {
 	if b.err != nil {
 		return 0, b.err
 	}
 	if w == nil {
-		return 0, errors.New("lambda/v2: nil writer")
+		return 0, errNilWriter
 	}
 	n, err := w.Write(b.v)
 	return int64(n), err
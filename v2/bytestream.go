@@ -0,0 +1,295 @@
+package v2
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"hash"
+	"io"
+	"sync"
+)
+
+// ByteStream is a lazy byte pipeline over an io.Reader. Transforms wrap the reader without
+// reading anything; sinks pull the data through every stage once, in constant memory, and close
+// all stages, joining read and close errors the way Slurp does.
+//
+// ByteStream implements io.ReadCloser, so it can itself be the source of other readers.
+type ByteStream struct {
+	r       io.Reader
+	closers []io.Closer // in stage order; closed in reverse
+	err     error
+}
+
+// onceCloser makes Close idempotent, so sinks and explicit Close calls can both close a stage.
+type onceCloser struct {
+	once sync.Once
+	c    io.Closer
+	err  error
+}
+
+func (o *onceCloser) Close() error {
+	o.once.Do(func() { o.err = o.c.Close() })
+	return o.err
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+// lazyReader defers building a reader until the first Read.
+type lazyReader struct {
+	init func() (io.Reader, error)
+	r    io.Reader
+	err  error
+}
+
+func (l *lazyReader) Read(p []byte) (int, error) {
+	if l.r == nil && l.err == nil {
+		l.r, l.err = l.init()
+	}
+	if l.err != nil {
+		return 0, l.err
+	}
+	return l.r.Read(p)
+}
+
+// ByteStreamOf starts a byte pipeline reading from r. r is not closed by the pipeline.
+func ByteStreamOf(r io.Reader) ByteStream {
+	if r == nil {
+		return ByteStream{err: errNilReader}
+	}
+	return ByteStream{r: r}
+}
+
+func byteStreamErr(err error) ByteStream { return ByteStream{err: err} }
+
+// Stream starts a byte pipeline reading from the contained io.Reader.
+func (r Reader) Stream() ByteStream {
+	if r.err != nil {
+		return byteStreamErr(r.err)
+	}
+	return ByteStreamOf(r.v)
+}
+
+// Stream starts a byte pipeline reading from the contained io.ReadCloser, which is closed
+// together with the pipeline.
+func (r ReadCloser) Stream() ByteStream {
+	if r.err != nil {
+		return byteStreamErr(r.err)
+	}
+	return ByteStreamOf(r.v).withCloser(r.v)
+}
+
+// Stream starts a byte pipeline reading from the contained bytes.
+func (b Bytes) Stream() ByteStream { return b.Reader().Stream() }
+
+// Stream starts a byte pipeline reading the response body, which is closed together with the
+// pipeline.
+func (r Resp) Stream() ByteStream {
+	if r.err != nil {
+		return byteStreamErr(r.err)
+	}
+	if r.v == nil {
+		return byteStreamErr(errors.New("lambda/v2: nil response"))
+	}
+	if r.v.Body == nil {
+		return byteStreamErr(errors.New("lambda/v2: nil response body"))
+	}
+	return ReadCloser{Ok[io.ReadCloser](r.v.Body)}.Stream()
+}
+
+// withCloser returns s with c appended to its stages to close.
+func (s ByteStream) withCloser(c io.Closer) ByteStream {
+	closers := make([]io.Closer, len(s.closers), len(s.closers)+1)
+	copy(closers, s.closers)
+	s.closers = append(closers, &onceCloser{c: c})
+	return s
+}
+
+// Err returns the construction error of the pipeline, if any. Read errors surface in the sinks.
+func (s ByteStream) Err() error { return s.err }
+
+// Read implements io.Reader.
+func (s ByteStream) Read(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	return s.r.Read(p)
+}
+
+// Close closes every stage, last stage first, and returns the joined errors. It is safe to call
+// Close after a sink has already closed the pipeline.
+func (s ByteStream) Close() error {
+	errs := make([]error, 0, len(s.closers))
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		errs = append(errs, s.closers[i].Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Through adds a custom stage: f wraps the current reader. If the returned reader implements
+// io.Closer it is closed with the pipeline. f is called lazily, on the first read.
+func (s ByteStream) Through(f func(io.Reader) (io.Reader, error)) ByteStream {
+	if s.err != nil {
+		return s
+	}
+	if f == nil {
+		return byteStreamErr(ErrNilFunc("Through")).withClosersOf(s)
+	}
+	src := s.r
+	var built io.Reader
+	s.r = &lazyReader{init: func() (io.Reader, error) {
+		r, err := f(src)
+		if err != nil {
+			return nil, err
+		}
+		built = r
+		return r, nil
+	}}
+	return s.withCloser(closerFunc(func() error {
+		if c, ok := built.(io.Closer); ok {
+			return c.Close()
+		}
+		return nil
+	}))
+}
+
+// withClosersOf keeps the stages of prev, so a failed transform still closes its source.
+func (s ByteStream) withClosersOf(prev ByteStream) ByteStream {
+	s.closers = prev.closers
+	return s
+}
+
+// pipe adds a stage that writes the current reader through the writer built by newW (e.g. a
+// compressor) in a goroutine and reads its output. The goroutine starts on the first read and
+// stops when the pipeline is closed.
+func (s ByteStream) pipe(newW func(io.Writer) (io.WriteCloser, error)) ByteStream {
+	if s.err != nil {
+		return s
+	}
+	src := s.r
+	pr, pw := io.Pipe()
+	s.r = &lazyReader{init: func() (io.Reader, error) {
+		go func() {
+			w, err := newW(pw)
+			if err == nil {
+				_, err = io.Copy(w, src)
+				err = errors.Join(err, w.Close())
+			}
+			pw.CloseWithError(err)
+		}()
+		return pr, nil
+	}}
+	return s.withCloser(pr)
+}
+
+// Gzip compresses the stream with the given level (e.g. gzip.DefaultCompression).
+func (s ByteStream) Gzip(level int) ByteStream {
+	return s.pipe(func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriterLevel(w, level) })
+}
+
+// Gunzip decompresses a gzip stream. Its output is capped at DefaultDecompressLimit.
+func (s ByteStream) Gunzip() ByteStream {
+	return s.Through(decompressor(gunzipReader).limited(DefaultDecompressLimit))
+}
+
+// Base64Encode encodes the stream as standard base64.
+func (s ByteStream) Base64Encode() ByteStream {
+	return s.pipe(func(w io.Writer) (io.WriteCloser, error) {
+		return base64.NewEncoder(base64.StdEncoding, w), nil
+	})
+}
+
+// Base64Decode decodes a standard base64 stream.
+func (s ByteStream) Base64Decode() ByteStream {
+	return s.Through(func(r io.Reader) (io.Reader, error) {
+		return base64.NewDecoder(base64.StdEncoding, r), nil
+	})
+}
+
+// Tee copies everything read through this stage to w.
+func (s ByteStream) Tee(w io.Writer) ByteStream {
+	if s.err != nil {
+		return s
+	}
+	if w == nil {
+		return byteStreamErr(errNilWriter).withClosersOf(s)
+	}
+	s.r = io.TeeReader(s.r, w)
+	return s
+}
+
+// Hash feeds everything read through this stage into h; read h.Sum once a sink has finished.
+func (s ByteStream) Hash(h hash.Hash) ByteStream { return s.Tee(h) }
+
+// Limit stops the stream after n bytes, like io.LimitReader.
+func (s ByteStream) Limit(n int64) ByteStream {
+	if s.err != nil {
+		return s
+	}
+	s.r = io.LimitReader(s.r, n)
+	return s
+}
+
+// WriteTo implements io.WriterTo: it copies the stream to w and closes the pipeline.
+func (s ByteStream) WriteTo(w io.Writer) (int64, error) {
+	if s.err != nil {
+		return 0, errors.Join(s.err, s.Close())
+	}
+	if w == nil {
+		return 0, errors.Join(errNilWriter, s.Close())
+	}
+	n, err := io.Copy(w, s.r)
+	return n, errors.Join(err, s.Close())
+}
+
+// WriteToWriter copies the stream to w, closes the pipeline and returns the bytes written.
+func (s ByteStream) WriteToWriter(w io.Writer) Option[int64] {
+	n, err := s.WriteTo(w)
+	return Wrap(n, err)
+}
+
+// Discard reads the stream to the end, closes the pipeline and returns the bytes read.
+func (s ByteStream) Discard() Option[int64] { return s.WriteToWriter(io.Discard) }
+
+// Digest reads the stream into h and returns h.Sum.
+func (s ByteStream) Digest(h hash.Hash) Bytes {
+	if h == nil {
+		return Bytes{Err[[]byte](errors.Join(errors.New("lambda/v2: nil hash"), s.Close()))}
+	}
+	if _, err := s.WriteTo(h); err != nil {
+		return Bytes{Err[[]byte](err)}
+	}
+	return Bytes{Ok(h.Sum(nil))}
+}
+
+// SHA256 returns the SHA256 checksum of the stream.
+func (s ByteStream) SHA256() SHA256Sum {
+	sum := s.Digest(sha256.New())
+	if sum.err != nil {
+		return SHA256Sum{Err[[sha256.Size]byte](sum.err)}
+	}
+	return SHA256Sum{Ok([sha256.Size]byte(sum.v))}
+}
+
+// Slurp reads the whole stream into memory, up to DefaultReadLimit, and closes the pipeline.
+func (s ByteStream) Slurp() Bytes { return s.SlurpLimit(DefaultReadLimit) }
+
+// SlurpLimit is Slurp failing with ErrTooLarge if the stream is longer than limit bytes
+// (no limit if <= 0).
+func (s ByteStream) SlurpLimit(limit int64) Bytes {
+	if s.err != nil {
+		return Bytes{Err[[]byte](errors.Join(s.err, s.Close()))}
+	}
+	b, err := readAllLimit(s.r, limit)
+	return Bytes{Wrap(b, errors.Join(err, s.Close()))}
+}
+
+// ReadCloser exposes the pipeline as a ReadCloser; closing it closes every stage.
+func (s ByteStream) ReadCloser() ReadCloser {
+	if s.err != nil {
+		return ReadCloser{Err[io.ReadCloser](errors.Join(s.err, s.Close()))}
+	}
+	return ReadCloser{Ok[io.ReadCloser](s)}
+}
@@ -0,0 +1,95 @@
+package v2
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// closeErrReader is a ReadCloser whose Close fails.
+type closeErrReader struct {
+	io.Reader
+	closed int
+}
+
+func (c *closeErrReader) Close() error {
+	c.closed++
+	return errors.New("close failed")
+}
+
+func TestByteStream_GzipRoundTripAndHash(t *testing.T) {
+	t.Parallel()
+
+	data := bytes.Repeat([]byte("lambda pipelines "), 10_000)
+	var compressed bytes.Buffer
+	n := BytesOf(data).Stream().Gzip(gzip.BestSpeed).Tee(&compressed).Discard().Must()
+	if n != int64(compressed.Len()) || n >= int64(len(data)) {
+		t.Fatalf("n=%d compressed=%d", n, compressed.Len())
+	}
+
+	h := sha256.New()
+	sum := ByteStreamOf(&compressed).Gunzip().Hash(h).SHA256().Must()
+	if sum != sha256.Sum256(data) || !bytes.Equal(h.Sum(nil), sum[:]) {
+		t.Fatal("checksum mismatch")
+	}
+}
+
+func TestByteStream_Base64AndLimit(t *testing.T) {
+	t.Parallel()
+
+	enc := StrOf("hello, world").Bytes().Stream().Base64Encode().Slurp().Must()
+	if string(enc) != "aGVsbG8sIHdvcmxk" {
+		t.Fatalf("encoded %q", enc)
+	}
+	dec := BytesOf(enc).Stream().Base64Decode().Limit(5).Slurp().Must()
+	if string(dec) != "hello" {
+		t.Fatalf("decoded %q", dec)
+	}
+}
+
+func TestByteStream_JoinsErrors(t *testing.T) {
+	t.Parallel()
+
+	src := &closeErrReader{Reader: strings.NewReader("definitely not gzip")}
+	s := ReadCloser{Ok[io.ReadCloser](src)}.Stream().Gunzip()
+	_, err := s.WriteToWriter(io.Discard).Get()
+	if !errors.Is(err, gzip.ErrHeader) || !strings.Contains(err.Error(), "close failed") {
+		t.Fatalf("err = %v", err)
+	}
+	s.Close()
+	if src.closed != 1 {
+		t.Fatalf("closed %d times", src.closed)
+	}
+
+	if _, err := Open("/does/not/exist").Stream().Gzip(gzip.DefaultCompression).Slurp().Get(); err == nil {
+		t.Fatal("expected open error")
+	}
+	if _, err := BytesOf(nil).Stream().Through(nil).Discard().Get(); err == nil {
+		t.Fatal("expected error for nil func")
+	}
+}
+
+func TestByteStream_CloseStopsPipe(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	// An endless source: only Close can stop the compressor goroutine.
+	s := ByteStreamOf(zeroReader{}).Gzip(gzip.BestSpeed)
+	buf := make([]byte, 1024)
+	if _, err := io.ReadFull(s, buf); err != nil {
+		t.Fatal(err)
+	}
+	mustErr(t, s.Close())
+	waitGoroutines(t, before)
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
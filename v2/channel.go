@@ -3,17 +3,85 @@ package v2
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"time"
 )
 
 type chanConfig struct {
-	buffer int
+	buffer   int
+	overflow Overflow
+	strategy FanOutStrategy
+	clock    Clock
+
+	maxAge     time.Duration
+	maxPending int
+
+	maxFailures     int
+	maxFailureRatio float64
+	minRatioItems   int
+
+	overlap OverlapPolicy
+
+	memLimit int
+	tempDir  string
+	stable   bool
+
+	maxEntrySize int64
+	maxTotalSize int64
+
+	watchFilter WalkFilter
+	watchHash   bool
+
+	maxLineLength int
+	longLines     LongLinePolicy
+
+	scope *Scope
+	meter *meter
+
+	features []featureOption
 }
 
 // ChanOption configures channel helpers (exporters/transforms).
+// Options that only apply to some helpers (WithOverflow, WithStrategy, ...) make every other
+// helper fail with an error instead of being ignored.
 type ChanOption func(*chanConfig)
 
-var errInvalidBuffer = errors.New("lambda/v2: buffer must be >= 0")
+var (
+	errInvalidBuffer     = errors.New("lambda/v2: buffer must be >= 0")
+	errUnsupportedOption = errors.New("lambda/v2: unsupported option")
+)
+
+// chanFeature is a group of options that only the helpers supporting it accept.
+type chanFeature uint
+
+// WithBuffer, WithScope and WithMetrics apply to every helper and need no feature. Every other
+// ChanOption must call needs with its feature, or helpers that don't support it silently ignore
+// it; a new option gets a new feature (or joins an existing one) and the helpers that honor it
+// pass that feature to chanCfg. Each feature lists its options and the helpers accepting it.
+const (
+	featBroadcast chanFeature = 1 << iota // WithOverflow: Broadcast
+	featFanOut                            // WithStrategy: FanOut
+	featJoin                              // WithMaxAge, WithMaxPending: JoinChan
+	featBudget                            // WithMaxFailures, WithMaxFailureRatio: SplitErrors
+	featSchedule                          // WithOverlap: Schedule
+	featSort                              // WithMemoryLimit, WithTempDir, WithStable: SortChan
+	featArchive                           // WithMaxEntrySize, WithMaxTotalSize: TarEntries, ZipEntries, ReadTar, ReadZip
+	featWatch                             // WithWatchFilter, WithContentHash: Watch
+	featLines                             // WithMaxLineLength, WithLongLines: ReadLines, Reader.Lines, Str.Lines, Proc.Lines
+	featClock                             // WithClock: the time-based helpers (Ticker, Debounce, Batch, Cron, ...)
+)
+
+type featureOption struct {
+	name    string
+	feature chanFeature
+}
+
+// needs records that the option name is only supported by helpers accepting f. Every
+// helper-specific option must call it; see chanFeature.
+func (c *chanConfig) needs(f chanFeature, name string) {
+	c.features = append(c.features, featureOption{name: name, feature: f})
+}
 
 // WithBuffer sets the buffer size of output channels created by channel helpers.
 // n must be >= 0.
@@ -26,19 +94,68 @@ func WithBuffer(n int) ChanOption {
 	}
 }
 
-func chanCfg(opts []ChanOption) (chanConfig, error) {
-	cfg := chanConfig{buffer: 0}
+// chanCfg applies opts. Feature options fail unless their feature is one of accept.
+func chanCfg(opts []ChanOption, accept ...chanFeature) (chanConfig, error) {
+	cfg := chanConfig{buffer: 0, clock: realClock{}}
 	for _, opt := range opts {
 		if opt != nil {
 			opt(&cfg)
 		}
 	}
+	var supported chanFeature
+	for _, f := range accept {
+		supported |= f
+	}
+	for _, f := range cfg.features {
+		if f.feature&supported == 0 {
+			return chanConfig{}, fmt.Errorf("%w %s", errUnsupportedOption, f.name)
+		}
+	}
 	if cfg.buffer < 0 {
 		return chanConfig{}, errInvalidBuffer
 	}
+	if cfg.clock == nil {
+		cfg.clock = realClock{}
+	}
 	return cfg, nil
 }
 
+// attach binds a helper's goroutine to the configured scope and metrics (if any).
+// It returns the context the goroutine must use and the error channel to hand out.
+func (c chanConfig) attach(ctx context.Context, name string, errc <-chan error) (context.Context, <-chan error) {
+	if c.meter != nil {
+		c.meter.parent = ctx
+		c.meter.scope = c.scope
+		c.meter.fifo = oneToOneStages[name]
+	}
+	switch {
+	case c.scope != nil:
+		return c.scope.attach(ctx, name, errc)
+	case c.meter != nil:
+		// Input proxies need to know when the stage stops reading.
+		sctx, cancel := context.WithCancelCause(ensureCtx(ctx))
+		out := make(chan error, 1)
+		go func() {
+			defer close(out)
+			err := <-errc
+			cancel(errStageDone)
+			out <- err
+		}()
+		return sctx, out
+	}
+	return ctx, errc
+}
+
+// oneToOneStages emit exactly one value per input, in order, so their per-value latency
+// can be measured.
+var oneToOneStages = map[string]bool{
+	"MapChan":     true,
+	"ScanChan":    true,
+	"TryChanEach": true,
+	"RateLimit":   true,
+	"Delay":       true,
+}
+
 func closedErrStream[T any](err error) (<-chan T, <-chan error) {
 	out := make(chan T)
 	close(out)
@@ -67,6 +184,7 @@ func Range(ctx context.Context, start, end int, opts ...ChanOption) (<-chan int,
 	}
 	out := make(chan int, cfg.buffer)
 	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Range", errc)
 
 	go func() {
 		defer close(out)
@@ -84,7 +202,7 @@ func Range(ctx context.Context, start, end int, opts ...ChanOption) (<-chan int,
 		errc <- nil
 	}()
 
-	return out, errc
+	return meterOut(cfg.meter, out), tracked
 }
 
 // RangeN emits ints from 0 to n-1.
@@ -100,6 +218,7 @@ func FromSlice[T any](ctx context.Context, xs []T, opts ...ChanOption) (<-chan T
 	}
 	out := make(chan T, cfg.buffer)
 	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "FromSlice", errc)
 
 	go func() {
 		defer close(out)
@@ -117,7 +236,7 @@ func FromSlice[T any](ctx context.Context, xs []T, opts ...ChanOption) (<-chan T
 		errc <- nil
 	}()
 
-	return out, errc
+	return meterOut(cfg.meter, out), tracked
 }
 
 // Repeat emits v indefinitely until ctx is canceled.
@@ -128,6 +247,7 @@ func Repeat[T any](ctx context.Context, v T, opts ...ChanOption) (<-chan T, <-ch
 	}
 	out := make(chan T, cfg.buffer)
 	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Repeat", errc)
 
 	go func() {
 		defer close(out)
@@ -144,7 +264,7 @@ func Repeat[T any](ctx context.Context, v T, opts ...ChanOption) (<-chan T, <-ch
 		}
 	}()
 
-	return out, errc
+	return meterOut(cfg.meter, out), tracked
 }
 
 // RepeatN emits v n times (or 0 times if n <= 0), then closes.
@@ -155,6 +275,7 @@ func RepeatN[T any](ctx context.Context, v T, n int, opts ...ChanOption) (<-chan
 	}
 	out := make(chan T, cfg.buffer)
 	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "RepeatN", errc)
 
 	go func() {
 		defer close(out)
@@ -172,7 +293,7 @@ func RepeatN[T any](ctx context.Context, v T, n int, opts ...ChanOption) (<-chan
 		errc <- nil
 	}()
 
-	return out, errc
+	return meterOut(cfg.meter, out), tracked
 }
 
 // GenFn generates values until it returns ok==false or an error.
@@ -189,6 +310,7 @@ func Generate[T any](ctx context.Context, gen GenFn[T], opts ...ChanOption) (<-c
 	}
 	out := make(chan T, cfg.buffer)
 	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Generate", errc)
 
 	go func() {
 		defer close(out)
@@ -220,7 +342,7 @@ func Generate[T any](ctx context.Context, gen GenFn[T], opts ...ChanOption) (<-c
 		}
 	}()
 
-	return out, errc
+	return meterOut(cfg.meter, out), tracked
 }
 
 // Take forwards up to n values from in to a new channel, then closes the output.
@@ -234,6 +356,8 @@ func Take[T any](ctx context.Context, in <-chan T, n int, opts ...ChanOption) (<
 	}
 	out := make(chan T, cfg.buffer)
 	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Take", errc)
+	in = meterIn(ctx, cfg.meter, in)
 
 	go func() {
 		defer close(out)
@@ -265,7 +389,7 @@ func Take[T any](ctx context.Context, in <-chan T, n int, opts ...ChanOption) (<
 		errc <- nil
 	}()
 
-	return out, errc
+	return meterOut(cfg.meter, out), tracked
 }
 
 // Drop skips the first n values from in, then forwards the rest to a new channel.
@@ -279,6 +403,8 @@ func Drop[T any](ctx context.Context, in <-chan T, n int, opts ...ChanOption) (<
 	}
 	out := make(chan T, cfg.buffer)
 	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Drop", errc)
+	in = meterIn(ctx, cfg.meter, in)
 
 	go func() {
 		defer close(out)
@@ -322,7 +448,7 @@ func Drop[T any](ctx context.Context, in <-chan T, n int, opts ...ChanOption) (<
 		}
 	}()
 
-	return out, errc
+	return meterOut(cfg.meter, out), tracked
 }
 
 // Peek consumes one element from in and returns it as first, and returns a new channel out
@@ -374,6 +500,8 @@ func Peek[T any](ctx context.Context, in <-chan T, opts ...ChanOption) (first Op
 		}
 		outCh := make(chan T, buf)
 		ec := make(chan error, 1)
+		ctx, tracked := cfg.attach(ctx, "Peek", ec)
+		in = meterIn(ctx, cfg.meter, in)
 		outCh <- v
 
 		go func() {
@@ -400,7 +528,7 @@ func Peek[T any](ctx context.Context, in <-chan T, opts ...ChanOption) (first Op
 			}
 		}()
 
-		return Ok(v), outCh, ec
+		return Ok(v), meterOut(cfg.meter, outCh), tracked
 	}
 }
 
@@ -419,6 +547,8 @@ func Tee[T any](ctx context.Context, in <-chan T, opts ...ChanOption) (out1 <-ch
 	a := make(chan T, cfg.buffer)
 	b := make(chan T, cfg.buffer)
 	ec := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Tee", ec)
+	in = meterIn(ctx, cfg.meter, in)
 
 	go func() {
 		defer close(a)
@@ -451,16 +581,25 @@ func Tee[T any](ctx context.Context, in <-chan T, opts ...ChanOption) (out1 <-ch
 		}
 	}()
 
-	return a, b, ec
+	return meterOut(cfg.meter.output("1"), a), meterOut(cfg.meter.output("2"), b), tracked
 }
 
 // Collect drains in into a slice and returns it as Option.
 func Collect[T any](ctx context.Context, in <-chan T, opts ...ChanOption) Option[[]T] {
-	_ = opts // reserved for future options (e.g. capacity hints)
 	if in == nil {
 		return Err[[]T](errNilChan)
 	}
+	cfg, err := chanCfg(opts)
+	if err != nil {
+		return Err[[]T](err)
+	}
 	ctx = ensureCtx(ctx)
+	if cfg.meter != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		in = meterIn(ctx, cfg.meter, in)
+	}
 	out := make([]T, 0)
 	for {
 		select {
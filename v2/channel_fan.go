@@ -0,0 +1,348 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Overflow decides what Broadcast does when a subscriber's buffer is full.
+type Overflow int
+
+const (
+	// OverflowBlock waits for the slow subscriber (default).
+	OverflowBlock Overflow = iota
+	// OverflowDrop drops the value for the slow subscriber only.
+	OverflowDrop
+)
+
+// FanOutStrategy decides which output FanOut sends a value to.
+type FanOutStrategy int
+
+const (
+	// RoundRobin sends values to outputs in turn (default).
+	RoundRobin FanOutStrategy = iota
+	// LeastLoaded sends each value to the output with the fewest buffered values,
+	// or to whichever consumer is ready first when all buffers are full.
+	LeastLoaded
+)
+
+var errInvalidCount = errors.New("lambda/v2: n must be >= 1")
+
+// WithOverflow sets the overflow policy used by Broadcast.
+func WithOverflow(p Overflow) ChanOption {
+	return func(c *chanConfig) {
+		if c == nil {
+			return
+		}
+		c.overflow = p
+		c.needs(featBroadcast, "WithOverflow")
+	}
+}
+
+// WithStrategy sets the distribution strategy used by FanOut.
+func WithStrategy(s FanOutStrategy) ChanOption {
+	return func(c *chanConfig) {
+		if c == nil {
+			return
+		}
+		c.strategy = s
+		c.needs(featFanOut, "WithStrategy")
+	}
+}
+
+func closedErrN[T any](n int, err error) ([]<-chan T, <-chan error) {
+	if n < 0 {
+		n = 0
+	}
+	outs := make([]<-chan T, n)
+	for i := range outs {
+		ch := make(chan T)
+		close(ch)
+		outs[i] = ch
+	}
+	errc := make(chan error, 1)
+	errc <- err
+	close(errc)
+	return outs, errc
+}
+
+func makeOuts[T any](n, buffer int) []chan T {
+	outs := make([]chan T, n)
+	for i := range outs {
+		outs[i] = make(chan T, buffer)
+	}
+	return outs
+}
+
+// Merge forwards values from all ins to a single output (fan-in). Order is not guaranteed.
+// The output closes once every input is closed.
+func Merge[T any](ctx context.Context, ins []<-chan T, opts ...ChanOption) (<-chan T, <-chan error) {
+	for _, in := range ins {
+		if in == nil {
+			return closedErrStream[T](errNilChan)
+		}
+	}
+	cfg, err := chanCfg(opts)
+	if err != nil {
+		return closedErrStream[T](err)
+	}
+	out := make(chan T, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Merge", errc)
+	if cfg.meter != nil {
+		metered := make([]<-chan T, len(ins))
+		for i, in := range ins {
+			metered[i] = meterIn(ctx, cfg.meter, in)
+		}
+		ins = metered
+	}
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		var (
+			wg          sync.WaitGroup
+			interrupted atomic.Bool
+		)
+		for _, in := range ins {
+			wg.Add(1)
+			go func(in <-chan T) {
+				defer wg.Done()
+				for {
+					select {
+					case <-ctx.Done():
+						interrupted.Store(true)
+						return
+					case v, ok := <-in:
+						if !ok {
+							return
+						}
+						select {
+						case <-ctx.Done():
+							interrupted.Store(true)
+							return
+						case out <- v:
+						}
+					}
+				}
+			}(in)
+		}
+		wg.Wait()
+		if interrupted.Load() {
+			errc <- ctx.Err()
+			return
+		}
+		errc <- nil
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+// Broadcast sends every value from in to n outputs.
+//
+// Each output gets its own buffer (see WithBuffer). With OverflowBlock (default) a slow
+// output holds back all others; with OverflowDrop values are dropped for full outputs only.
+func Broadcast[T any](ctx context.Context, in <-chan T, n int, opts ...ChanOption) ([]<-chan T, <-chan error) {
+	if in == nil {
+		return closedErrN[T](n, errNilChan)
+	}
+	if n < 1 {
+		return closedErrN[T](0, errInvalidCount)
+	}
+	cfg, err := chanCfg(opts, featBroadcast)
+	if err != nil {
+		return closedErrN[T](n, err)
+	}
+	outs := makeOuts[T](n, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Broadcast", errc)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer func() {
+			for _, o := range outs {
+				close(o)
+			}
+		}()
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case v, ok := <-in:
+				if !ok {
+					errc <- nil
+					return
+				}
+				for _, o := range outs {
+					if cfg.overflow == OverflowDrop {
+						select {
+						case o <- v:
+						default:
+						}
+						continue
+					}
+					select {
+					case <-ctx.Done():
+						errc <- ctx.Err()
+						return
+					case o <- v:
+					}
+				}
+			}
+		}
+	}()
+
+	return meterOuts(cfg.meter, outs), tracked
+}
+
+// FanOut distributes values from in across n outputs; every value goes to exactly one output.
+// See WithStrategy for the distribution strategies.
+func FanOut[T any](ctx context.Context, in <-chan T, n int, opts ...ChanOption) ([]<-chan T, <-chan error) {
+	if in == nil {
+		return closedErrN[T](n, errNilChan)
+	}
+	if n < 1 {
+		return closedErrN[T](0, errInvalidCount)
+	}
+	cfg, err := chanCfg(opts, featFanOut)
+	if err != nil {
+		return closedErrN[T](n, err)
+	}
+	outs := makeOuts[T](n, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "FanOut", errc)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer func() {
+			for _, o := range outs {
+				close(o)
+			}
+		}()
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		next := 0
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case v, ok := <-in:
+				if !ok {
+					errc <- nil
+					return
+				}
+				var sent bool
+				if cfg.strategy == LeastLoaded {
+					sent = sendLeastLoaded(ctx, outs, v)
+				} else {
+					select {
+					case <-ctx.Done():
+					case outs[next] <- v:
+						sent = true
+					}
+					next = (next + 1) % n
+				}
+				if !sent {
+					errc <- ctx.Err()
+					return
+				}
+			}
+		}
+	}()
+
+	return meterOuts(cfg.meter, outs), tracked
+}
+
+// sendLeastLoaded sends v to the output with the fewest buffered values. If every buffer is
+// full it waits for whichever output accepts first. It reports false if ctx was canceled.
+func sendLeastLoaded[T any](ctx context.Context, outs []chan T, v T) bool {
+	best := -1
+	for i, o := range outs {
+		if len(o) < cap(o) && (best < 0 || len(o) < len(outs[best])) {
+			best = i
+		}
+	}
+	if best >= 0 {
+		// Only this goroutine sends, so a non-full buffer cannot fill up concurrently.
+		outs[best] <- v
+		return true
+	}
+
+	cases := make([]reflect.SelectCase, 0, len(outs)+1)
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())})
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		// v is a nil interface value.
+		rv = reflect.Zero(reflect.TypeOf(outs[0]).Elem())
+	}
+	for _, o := range outs {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(o), Send: rv})
+	}
+	chosen, _, _ := reflect.Select(cases)
+	return chosen != 0
+}
+
+// Partition splits in into values for which pred returns true (matched) and the rest.
+// It blocks if either output blocks (unless buffered).
+func Partition[T any](ctx context.Context, in <-chan T, pred FilterFn[T], opts ...ChanOption) (matched <-chan T, unmatched <-chan T, errc <-chan error) {
+	if in == nil {
+		return closedErr2[T](errNilChan)
+	}
+	if pred == nil {
+		return closedErr2[T](ErrNilFunc("Partition"))
+	}
+	cfg, err := chanCfg(opts)
+	if err != nil {
+		return closedErr2[T](err)
+	}
+
+	ctx = ensureCtx(ctx)
+
+	a := make(chan T, cfg.buffer)
+	b := make(chan T, cfg.buffer)
+	ec := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Partition", ec)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer close(a)
+		defer close(b)
+		defer close(ec)
+
+		for {
+			select {
+			case <-ctx.Done():
+				ec <- ctx.Err()
+				return
+			case v, ok := <-in:
+				if !ok {
+					ec <- nil
+					return
+				}
+				dst := b
+				if pred(v) {
+					dst = a
+				}
+				select {
+				case <-ctx.Done():
+					ec <- ctx.Err()
+					return
+				case dst <- v:
+				}
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter.output("matched"), a), meterOut(cfg.meter.output("unmatched"), b), tracked
+}
@@ -0,0 +1,173 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	a, aErrc := Range(context.Background(), 0, 5)
+	b, bErrc := Range(context.Background(), 5, 10)
+	c, cErrc := Range(context.Background(), 10, 12)
+	out, errc := Merge(context.Background(), []<-chan int{a, b, c})
+	got := Collect(context.Background(), out).Must()
+	mustErr(t, JoinErr(aErrc, bErrc, cErrc, errc))
+
+	sort.Ints(got)
+	if len(got) != 12 {
+		t.Fatalf("len=%d, want 12", len(got))
+	}
+	for i := range got {
+		if got[i] != i {
+			t.Fatalf("got %v", got)
+		}
+	}
+}
+
+func TestMerge_NilChan(t *testing.T) {
+	t.Parallel()
+
+	out, errc := Merge(context.Background(), []<-chan int{nil})
+	_ = Collect(context.Background(), out).Must()
+	if err := <-errc; err == nil || err.Error() != "lambda/v2: nil input channel" {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+// collectAll drains every channel concurrently.
+func collectAll[T any](chs []<-chan T) [][]T {
+	got := make([][]T, len(chs))
+	var wg sync.WaitGroup
+	for i, ch := range chs {
+		wg.Add(1)
+		go func(i int, ch <-chan T) {
+			defer wg.Done()
+			got[i] = Collect(context.Background(), ch).Must()
+		}(i, ch)
+	}
+	wg.Wait()
+	return got
+}
+
+func TestBroadcast(t *testing.T) {
+	t.Parallel()
+
+	src, srcErrc := RangeN(context.Background(), 5)
+	outs, errc := Broadcast(context.Background(), src, 3)
+	got := collectAll(outs)
+	mustErr(t, JoinErr(srcErrc, errc))
+	for i, g := range got {
+		if want := []int{0, 1, 2, 3, 4}; !intsEqual(g, want) {
+			t.Fatalf("out[%d]=%v, want %v", i, g, want)
+		}
+	}
+}
+
+func TestBroadcast_DropSlowSubscriber(t *testing.T) {
+	t.Parallel()
+
+	src, srcErrc := RangeN(context.Background(), 10)
+	outs, errc := Broadcast(context.Background(), src, 2, WithBuffer(2), WithOverflow(OverflowDrop))
+
+	// Only the first subscriber is read while the stream runs.
+	fast := Collect(context.Background(), outs[0]).Must()
+	mustErr(t, JoinErr(srcErrc, errc))
+	slow := Collect(context.Background(), outs[1]).Must()
+
+	if len(fast) == 0 {
+		t.Fatalf("fast subscriber got nothing")
+	}
+	if want := []int{0, 1}; !intsEqual(slow, want) {
+		t.Fatalf("slow=%v, want %v", slow, want)
+	}
+}
+
+func TestFanOut_RoundRobin(t *testing.T) {
+	t.Parallel()
+
+	src, srcErrc := RangeN(context.Background(), 9)
+	outs, errc := FanOut(context.Background(), src, 3, WithBuffer(9))
+	mustErr(t, <-srcErrc)
+	mustErr(t, <-errc)
+	got := collectAll(outs)
+	for i, g := range got {
+		if want := []int{i, i + 3, i + 6}; !intsEqual(g, want) {
+			t.Fatalf("out[%d]=%v, want %v", i, g, want)
+		}
+	}
+}
+
+func TestFanOut_LeastLoaded(t *testing.T) {
+	t.Parallel()
+
+	src, srcErrc := RangeN(context.Background(), 100)
+	outs, errc := FanOut(context.Background(), src, 4, WithStrategy(LeastLoaded), WithBuffer(1))
+	got := collectAll(outs)
+	mustErr(t, JoinErr(srcErrc, errc))
+
+	var all []int
+	for _, g := range got {
+		all = append(all, g...)
+	}
+	sort.Ints(all)
+	if len(all) != 100 {
+		t.Fatalf("len=%d, want 100", len(all))
+	}
+	for i := range all {
+		if all[i] != i {
+			t.Fatalf("value %d missing or duplicated", i)
+		}
+	}
+}
+
+func TestFanOut_InvalidCount(t *testing.T) {
+	t.Parallel()
+
+	in := make(chan int)
+	outs, errc := FanOut(context.Background(), in, 0)
+	if len(outs) != 0 {
+		t.Fatalf("len(outs)=%d", len(outs))
+	}
+	if err := <-errc; !errors.Is(err, errInvalidCount) {
+		t.Fatalf("err=%v", err)
+	}
+
+	// WithOverflow only applies to Broadcast.
+	_, errc = FanOut(context.Background(), in, 2, WithOverflow(OverflowDrop))
+	if err := <-errc; !errors.Is(err, errUnsupportedOption) {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestPartition(t *testing.T) {
+	t.Parallel()
+
+	src, srcErrc := RangeN(context.Background(), 6)
+	even, odd, errc := Partition(context.Background(), src, FilterFn[int](func(v int) bool { return v%2 == 0 }), WithBuffer(6))
+	mustErr(t, JoinErr(srcErrc, errc))
+	if got, want := Collect(context.Background(), even).Must(), []int{0, 2, 4}; !intsEqual(got, want) {
+		t.Fatalf("even=%v, want %v", got, want)
+	}
+	if got, want := Collect(context.Background(), odd).Must(), []int{1, 3, 5}; !intsEqual(got, want) {
+		t.Fatalf("odd=%v, want %v", got, want)
+	}
+}
+
+func TestFanOut_Cancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	src, srcErrc := Repeat(ctx, 1)
+	outs, errc := FanOut(ctx, src, 2)
+	<-outs[0]
+	cancel()
+	_ = collectAll(outs)
+	if err := JoinErr(srcErrc, errc); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err=%v, want context.Canceled", err)
+	}
+}
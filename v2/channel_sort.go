@@ -0,0 +1,257 @@
+package v2
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"os"
+	"sort"
+)
+
+// LessFn reports whether a sorts before b.
+type LessFn[T any] func(a, b T) bool
+
+const defaultSortMemoryLimit = 100_000
+
+// WithMemoryLimit sets how many values SortChan keeps in memory; larger inputs are sorted in
+// runs of n values that are spilled to temporary files. n must be >= 1.
+func WithMemoryLimit(n int) ChanOption {
+	return func(c *chanConfig) {
+		if c == nil {
+			return
+		}
+		c.memLimit = n
+		c.needs(featSort, "WithMemoryLimit")
+	}
+}
+
+// WithTempDir sets the directory below which SortChan creates its temporary files
+// (os.TempDir() by default).
+func WithTempDir(dir string) ChanOption {
+	return func(c *chanConfig) {
+		if c == nil {
+			return
+		}
+		c.tempDir = dir
+		c.needs(featSort, "WithTempDir")
+	}
+}
+
+// WithStable makes SortChan keep equal values in input order.
+func WithStable() ChanOption {
+	return func(c *chanConfig) {
+		if c == nil {
+			return
+		}
+		c.stable = true
+		c.needs(featSort, "WithStable")
+	}
+}
+
+// sortRun is a sorted run being merged: an in-memory slice or a spilled segment.
+type sortRun[T any] struct {
+	idx  int // position in input order, breaks ties for stable merges
+	head T
+	mem  []T
+	seg  *segment[T]
+}
+
+// advance loads the next value into head. It reports false once the run is exhausted.
+func (r *sortRun[T]) advance() (bool, error) {
+	if r.seg != nil {
+		if r.seg.n == 0 {
+			return false, r.seg.remove()
+		}
+		v, err := r.seg.read()
+		if err != nil {
+			return false, err
+		}
+		r.head = v
+		return true, nil
+	}
+	if len(r.mem) == 0 {
+		return false, nil
+	}
+	r.head, r.mem = r.mem[0], r.mem[1:]
+	return true, nil
+}
+
+type mergeHeap[T any] struct {
+	runs []*sortRun[T]
+	less LessFn[T]
+}
+
+func (h *mergeHeap[T]) Len() int { return len(h.runs) }
+
+func (h *mergeHeap[T]) Less(i, j int) bool {
+	a, b := h.runs[i], h.runs[j]
+	if h.less(a.head, b.head) {
+		return true
+	}
+	if h.less(b.head, a.head) {
+		return false
+	}
+	return a.idx < b.idx
+}
+
+func (h *mergeHeap[T]) Swap(i, j int) { h.runs[i], h.runs[j] = h.runs[j], h.runs[i] }
+func (h *mergeHeap[T]) Push(x any)    { h.runs = append(h.runs, x.(*sortRun[T])) }
+
+func (h *mergeHeap[T]) Pop() any {
+	r := h.runs[len(h.runs)-1]
+	h.runs = h.runs[:len(h.runs)-1]
+	return r
+}
+
+// SortChan sorts all values from in by less and emits them once in is closed.
+//
+// Up to WithMemoryLimit values are sorted in memory; larger inputs are sorted in runs that are
+// spilled to temporary files (see WithTempDir) using codec and merged back k-way; a nil codec
+// means GobCodec[T]. WithStable keeps equal values in input order.
+func SortChan[T any](ctx context.Context, in <-chan T, less LessFn[T], codec Codec[T], opts ...ChanOption) (<-chan T, <-chan error) {
+	if in == nil {
+		return closedErrStream[T](errNilChan)
+	}
+	if less == nil {
+		return closedErrStream[T](ErrNilFunc("SortChan"))
+	}
+	cfg, err := chanCfg(opts, featSort)
+	if err != nil {
+		return closedErrStream[T](err)
+	}
+	if cfg.memLimit == 0 {
+		cfg.memLimit = defaultSortMemoryLimit
+	}
+	if cfg.memLimit < 1 {
+		return closedErrStream[T](errInvalidMemLimit)
+	}
+	if codec == nil {
+		codec = GobCodec[T]{}
+	}
+	out := make(chan T, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "SortChan", errc)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		var (
+			buf  []T
+			runs []*sortRun[T]
+			tmp  string
+		)
+		cleanup := func() error {
+			var errs []error
+			for _, r := range runs {
+				if r.seg != nil && r.seg.f != nil {
+					errs = append(errs, r.seg.remove())
+				}
+			}
+			if tmp != "" {
+				errs = append(errs, os.RemoveAll(tmp))
+			}
+			return errors.Join(errs...)
+		}
+		fail := func(err error) {
+			errc <- errors.Join(err, cleanup())
+		}
+		sortBuf := func() {
+			if cfg.stable {
+				sort.SliceStable(buf, func(i, j int) bool { return less(buf[i], buf[j]) })
+			} else {
+				sort.Slice(buf, func(i, j int) bool { return less(buf[i], buf[j]) })
+			}
+		}
+		spill := func() error {
+			sortBuf()
+			if tmp == "" {
+				dir, err := os.MkdirTemp(cfg.tempDir, "lambda-sort-")
+				if err != nil {
+					return err
+				}
+				tmp = dir
+			}
+			seg, err := createSegment(tmp, len(runs), codec)
+			if err != nil {
+				return err
+			}
+			runs = append(runs, &sortRun[T]{idx: len(runs), seg: seg})
+			for _, v := range buf {
+				if err := seg.write(v); err != nil {
+					return err
+				}
+			}
+			clear(buf)
+			buf = buf[:0]
+			return seg.seal(codec)
+		}
+
+	read:
+		for {
+			select {
+			case <-ctx.Done():
+				fail(ctx.Err())
+				return
+			case v, ok := <-in:
+				if !ok {
+					break read
+				}
+				buf = append(buf, v)
+				if len(buf) >= cfg.memLimit {
+					if err := spill(); err != nil {
+						fail(err)
+						return
+					}
+				}
+			}
+		}
+
+		// Upstream may have closed early because ctx was canceled.
+		if err := ctx.Err(); err != nil {
+			fail(err)
+			return
+		}
+		sortBuf()
+		if len(buf) > 0 {
+			runs = append(runs, &sortRun[T]{idx: len(runs), mem: buf})
+		}
+		h := &mergeHeap[T]{less: less}
+		for _, r := range runs {
+			ok, err := r.advance()
+			if err != nil {
+				fail(err)
+				return
+			}
+			if ok {
+				h.runs = append(h.runs, r)
+			}
+		}
+		heap.Init(h)
+
+		for h.Len() > 0 {
+			r := h.runs[0]
+			select {
+			case <-ctx.Done():
+				fail(ctx.Err())
+				return
+			case out <- r.head:
+			}
+			ok, err := r.advance()
+			if err != nil {
+				fail(err)
+				return
+			}
+			if ok {
+				heap.Fix(h, 0)
+			} else {
+				heap.Pop(h)
+			}
+		}
+		errc <- cleanup()
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
@@ -0,0 +1,131 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"sync/atomic"
+	"testing"
+)
+
+// countingCodec wraps JSONCodec and counts encoded values.
+type countingCodec[T any] struct {
+	JSONCodec[T]
+	n *atomic.Int64
+}
+
+func (c countingCodec[T]) NewEncoder(w io.Writer) Encoder[T] {
+	return countingEncoder[T]{c.JSONCodec.NewEncoder(w), c.n}
+}
+
+type countingEncoder[T any] struct {
+	Encoder[T]
+	n *atomic.Int64
+}
+
+func (e countingEncoder[T]) Encode(v T) error {
+	e.n.Add(1)
+	return e.Encoder.Encode(v)
+}
+
+func intLess(a, b int) bool { return a < b }
+
+func TestSortChan_InMemory(t *testing.T) {
+	t.Parallel()
+
+	src, srcErrc := FromSlice(context.Background(), []int{5, 3, 9, 1, 3})
+	out, errc := SortChan(context.Background(), src, intLess, nil)
+	got := Collect(nil, out).Must()
+	mustErr(t, JoinErr(srcErrc, errc))
+	if !intsEqual(got, []int{1, 3, 3, 5, 9}) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestSortChan_Spills(t *testing.T) {
+	t.Parallel()
+
+	rng := rand.New(rand.NewSource(1))
+	xs := make([]int, 1000)
+	for i := range xs {
+		xs[i] = rng.Intn(500)
+	}
+	want := append([]int(nil), xs...)
+	sort.Ints(want)
+
+	dir := t.TempDir()
+	var encoded atomic.Int64
+	src, srcErrc := FromSlice(context.Background(), xs)
+	out, errc := SortChan(context.Background(), src, intLess,
+		countingCodec[int]{n: &encoded}, WithMemoryLimit(64), WithTempDir(dir))
+	got := Collect(nil, out).Must()
+	mustErr(t, JoinErr(srcErrc, errc))
+
+	if !intsEqual(got, want) {
+		t.Fatalf("not sorted: %v", got[:20])
+	}
+	// 15 full runs are spilled, the last 40 values stay in memory.
+	if n := encoded.Load(); n != 960 {
+		t.Fatalf("spilled %d values", n)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("left behind: %v", entries)
+	}
+}
+
+type sortRec struct {
+	Key, Seq int
+}
+
+func TestSortChan_Stable(t *testing.T) {
+	t.Parallel()
+
+	var recs []sortRec
+	for i := 0; i < 40; i++ {
+		recs = append(recs, sortRec{Key: (i * 7) % 4, Seq: i})
+	}
+	src, srcErrc := FromSlice(context.Background(), recs)
+	out, errc := SortChan(context.Background(), src, func(a, b sortRec) bool { return a.Key < b.Key }, nil,
+		WithStable(), WithMemoryLimit(6), WithTempDir(t.TempDir()))
+	got := Collect(nil, out).Must()
+	mustErr(t, JoinErr(srcErrc, errc))
+
+	if len(got) != len(recs) {
+		t.Fatalf("got %d values", len(got))
+	}
+	for i := 1; i < len(got); i++ {
+		a, b := got[i-1], got[i]
+		if a.Key > b.Key || (a.Key == b.Key && a.Seq > b.Seq) {
+			t.Fatalf("unstable at %d: %+v then %+v", i, a, b)
+		}
+	}
+}
+
+func TestSortChan_Errors(t *testing.T) {
+	t.Parallel()
+
+	in := make(chan int)
+	close(in)
+	if _, errc := SortChan(nil, in, nil, nil); <-errc == nil {
+		t.Fatal("expected error for nil less")
+	}
+	if _, errc := SortChan(nil, in, intLess, nil, WithMemoryLimit(-1)); !errors.Is(<-errc, errInvalidMemLimit) {
+		t.Fatal("expected errInvalidMemLimit")
+	}
+	if _, errc := MapChan(nil, in, func(v int) int { return v }, WithStable()); !errors.Is(<-errc, errUnsupportedOption) {
+		t.Fatal("expected errUnsupportedOption")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ones, e1 := Repeat(ctx, 1)
+	out, errc := SortChan(ctx, ones, intLess, nil, WithMemoryLimit(8), WithTempDir(t.TempDir()))
+	cancel()
+	Drain(nil, out)
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v", err)
+	}
+	<-e1
+}
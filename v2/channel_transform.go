@@ -0,0 +1,397 @@
+package v2
+
+import "context"
+
+// FilterFn decides whether a value is kept.
+type FilterFn[T any] func(T) bool
+
+// FlatMapFn expands a T into zero or more Us.
+type FlatMapFn[T, U any] func(T) []U
+
+// FoldFn combines an accumulator with the next value.
+type FoldFn[A, T any] func(A, T) A
+
+// KeyFn derives a comparable key from a value.
+type KeyFn[T any, K comparable] func(T) K
+
+// MapChan transforms each value read from in using f. Order is preserved.
+func MapChan[T, U any](ctx context.Context, in <-chan T, f MapFn[T, U], opts ...ChanOption) (<-chan U, <-chan error) {
+	if in == nil {
+		return closedErrStream[U](errNilChan)
+	}
+	if f == nil {
+		return closedErrStream[U](ErrNilFunc("MapChan"))
+	}
+	cfg, err := chanCfg(opts)
+	if err != nil {
+		return closedErrStream[U](err)
+	}
+	out := make(chan U, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "MapChan", errc)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case v, ok := <-in:
+				if !ok {
+					errc <- nil
+					return
+				}
+				select {
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				case out <- f(v):
+				}
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+// FilterChan forwards the values read from in for which keep returns true.
+func FilterChan[T any](ctx context.Context, in <-chan T, keep FilterFn[T], opts ...ChanOption) (<-chan T, <-chan error) {
+	if in == nil {
+		return closedErrStream[T](errNilChan)
+	}
+	if keep == nil {
+		return closedErrStream[T](ErrNilFunc("FilterChan"))
+	}
+	cfg, err := chanCfg(opts)
+	if err != nil {
+		return closedErrStream[T](err)
+	}
+	out := make(chan T, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "FilterChan", errc)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case v, ok := <-in:
+				if !ok {
+					errc <- nil
+					return
+				}
+				if !keep(v) {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				case out <- v:
+				}
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+// FlatMapChan expands each value read from in using f and forwards all results in order.
+func FlatMapChan[T, U any](ctx context.Context, in <-chan T, f FlatMapFn[T, U], opts ...ChanOption) (<-chan U, <-chan error) {
+	if in == nil {
+		return closedErrStream[U](errNilChan)
+	}
+	if f == nil {
+		return closedErrStream[U](ErrNilFunc("FlatMapChan"))
+	}
+	cfg, err := chanCfg(opts)
+	if err != nil {
+		return closedErrStream[U](err)
+	}
+	out := make(chan U, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "FlatMapChan", errc)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case v, ok := <-in:
+				if !ok {
+					errc <- nil
+					return
+				}
+				for _, u := range f(v) {
+					select {
+					case <-ctx.Done():
+						errc <- ctx.Err()
+						return
+					case out <- u:
+					}
+				}
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+// ScanChan folds the values read from in and emits every intermediate accumulator.
+func ScanChan[T, A any](ctx context.Context, in <-chan T, init A, f FoldFn[A, T], opts ...ChanOption) (<-chan A, <-chan error) {
+	if in == nil {
+		return closedErrStream[A](errNilChan)
+	}
+	if f == nil {
+		return closedErrStream[A](ErrNilFunc("ScanChan"))
+	}
+	cfg, err := chanCfg(opts)
+	if err != nil {
+		return closedErrStream[A](err)
+	}
+	out := make(chan A, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "ScanChan", errc)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		acc := init
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case v, ok := <-in:
+				if !ok {
+					errc <- nil
+					return
+				}
+				acc = f(acc, v)
+				select {
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				case out <- acc:
+				}
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+// ReduceChan folds the values read from in and emits the final accumulator once in is closed.
+// If in is empty, init is emitted. Nothing is emitted if ctx is canceled first.
+func ReduceChan[T, A any](ctx context.Context, in <-chan T, init A, f FoldFn[A, T], opts ...ChanOption) (<-chan A, <-chan error) {
+	if in == nil {
+		return closedErrStream[A](errNilChan)
+	}
+	if f == nil {
+		return closedErrStream[A](ErrNilFunc("ReduceChan"))
+	}
+	cfg, err := chanCfg(opts)
+	if err != nil {
+		return closedErrStream[A](err)
+	}
+	out := make(chan A, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "ReduceChan", errc)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		acc := init
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case v, ok := <-in:
+				if !ok {
+					select {
+					case <-ctx.Done():
+						errc <- ctx.Err()
+					case out <- acc:
+						errc <- nil
+					}
+					return
+				}
+				acc = f(acc, v)
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+// DistinctChan forwards only the first value seen for every key.
+//
+// All keys are remembered for the lifetime of the stage.
+func DistinctChan[T any, K comparable](ctx context.Context, in <-chan T, key KeyFn[T, K], opts ...ChanOption) (<-chan T, <-chan error) {
+	if in == nil {
+		return closedErrStream[T](errNilChan)
+	}
+	if key == nil {
+		return closedErrStream[T](ErrNilFunc("DistinctChan"))
+	}
+	cfg, err := chanCfg(opts)
+	if err != nil {
+		return closedErrStream[T](err)
+	}
+	out := make(chan T, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "DistinctChan", errc)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		seen := make(map[K]struct{})
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case v, ok := <-in:
+				if !ok {
+					errc <- nil
+					return
+				}
+				k := key(v)
+				if _, dup := seen[k]; dup {
+					continue
+				}
+				seen[k] = struct{}{}
+				select {
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				case out <- v:
+				}
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+// TakeWhile forwards values from in while pred returns true, then closes the output.
+func TakeWhile[T any](ctx context.Context, in <-chan T, pred FilterFn[T], opts ...ChanOption) (<-chan T, <-chan error) {
+	if in == nil {
+		return closedErrStream[T](errNilChan)
+	}
+	if pred == nil {
+		return closedErrStream[T](ErrNilFunc("TakeWhile"))
+	}
+	cfg, err := chanCfg(opts)
+	if err != nil {
+		return closedErrStream[T](err)
+	}
+	out := make(chan T, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "TakeWhile", errc)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case v, ok := <-in:
+				if !ok || !pred(v) {
+					errc <- nil
+					return
+				}
+				select {
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				case out <- v:
+				}
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+// DropWhile skips values from in while pred returns true, then forwards the rest.
+func DropWhile[T any](ctx context.Context, in <-chan T, pred FilterFn[T], opts ...ChanOption) (<-chan T, <-chan error) {
+	if in == nil {
+		return closedErrStream[T](errNilChan)
+	}
+	if pred == nil {
+		return closedErrStream[T](ErrNilFunc("DropWhile"))
+	}
+	cfg, err := chanCfg(opts)
+	if err != nil {
+		return closedErrStream[T](err)
+	}
+	out := make(chan T, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "DropWhile", errc)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		dropping := true
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case v, ok := <-in:
+				if !ok {
+					errc <- nil
+					return
+				}
+				if dropping && pred(v) {
+					continue
+				}
+				dropping = false
+				select {
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				case out <- v:
+				}
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
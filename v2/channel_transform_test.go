@@ -0,0 +1,142 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func intsEqual(got, want []int) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMapChan(t *testing.T) {
+	t.Parallel()
+
+	src, srcErrc := RangeN(context.Background(), 4)
+	out, errc := MapChan(context.Background(), src, MapFn[int, int](func(v int) int { return v * v }))
+	got := Collect(context.Background(), out).Must()
+	mustErr(t, JoinErr(srcErrc, errc))
+	if want := []int{0, 1, 4, 9}; !intsEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestFilterChan(t *testing.T) {
+	t.Parallel()
+
+	src, srcErrc := RangeN(context.Background(), 10)
+	out, errc := FilterChan(context.Background(), src, FilterFn[int](func(v int) bool { return v%3 == 0 }))
+	got := Collect(context.Background(), out).Must()
+	mustErr(t, JoinErr(srcErrc, errc))
+	if want := []int{0, 3, 6, 9}; !intsEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestFlatMapChan(t *testing.T) {
+	t.Parallel()
+
+	src, srcErrc := FromSlice(context.Background(), []string{"a b", "", "c"})
+	out, errc := FlatMapChan(context.Background(), src, FlatMapFn[string, string](strings.Fields))
+	got := Collect(context.Background(), out).Must()
+	mustErr(t, JoinErr(srcErrc, errc))
+	if strings.Join(got, ",") != "a,b,c" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestScanAndReduceChan(t *testing.T) {
+	t.Parallel()
+
+	sum := FoldFn[int, int](func(acc, v int) int { return acc + v })
+
+	src, srcErrc := Range(context.Background(), 1, 5)
+	scan, scanErrc := ScanChan(context.Background(), src, 0, sum)
+	got := Collect(context.Background(), scan).Must()
+	mustErr(t, JoinErr(srcErrc, scanErrc))
+	if want := []int{1, 3, 6, 10}; !intsEqual(got, want) {
+		t.Fatalf("scan got %v, want %v", got, want)
+	}
+
+	src2, src2Errc := Range(context.Background(), 1, 5)
+	red, redErrc := ReduceChan(context.Background(), src2, 0, sum)
+	got = Collect(context.Background(), red).Must()
+	mustErr(t, JoinErr(src2Errc, redErrc))
+	if want := []int{10}; !intsEqual(got, want) {
+		t.Fatalf("reduce got %v, want %v", got, want)
+	}
+
+	empty := make(chan int)
+	close(empty)
+	red, redErrc = ReduceChan(context.Background(), empty, 42, sum)
+	got = Collect(context.Background(), red).Must()
+	mustErr(t, <-redErrc)
+	if want := []int{42}; !intsEqual(got, want) {
+		t.Fatalf("empty reduce got %v, want %v", got, want)
+	}
+}
+
+func TestDistinctChan(t *testing.T) {
+	t.Parallel()
+
+	src, srcErrc := FromSlice(context.Background(), []string{"a", "B", "b", "A", "c"})
+	out, errc := DistinctChan(context.Background(), src, KeyFn[string, string](strings.ToLower))
+	got := Collect(context.Background(), out).Must()
+	mustErr(t, JoinErr(srcErrc, errc))
+	if strings.Join(got, ",") != "a,B,c" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestTakeWhileDropWhile(t *testing.T) {
+	t.Parallel()
+
+	small := FilterFn[int](func(v int) bool { return v < 3 })
+
+	src, srcErrc := FromSlice(context.Background(), []int{1, 2, 3, 1, 4}, WithBuffer(5))
+	tw, twErrc := TakeWhile(context.Background(), src, small)
+	got := Collect(context.Background(), tw).Must()
+	mustErr(t, JoinErr(srcErrc, twErrc))
+	if want := []int{1, 2}; !intsEqual(got, want) {
+		t.Fatalf("takeWhile got %v, want %v", got, want)
+	}
+
+	src2, src2Errc := FromSlice(context.Background(), []int{1, 2, 3, 1, 4})
+	dw, dwErrc := DropWhile(context.Background(), src2, small)
+	got = Collect(context.Background(), dw).Must()
+	mustErr(t, JoinErr(src2Errc, dwErrc))
+	if want := []int{3, 1, 4}; !intsEqual(got, want) {
+		t.Fatalf("dropWhile got %v, want %v", got, want)
+	}
+}
+
+func TestTransforms_NilFuncAndCancel(t *testing.T) {
+	t.Parallel()
+
+	in := make(chan int)
+	out, errc := MapChan[int, int](context.Background(), in, nil)
+	_ = Collect(context.Background(), out).Must()
+	if err := <-errc; err == nil || err.Error() != ErrNilFunc("MapChan").Error() {
+		t.Fatalf("err=%v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	red, redErrc := ReduceChan(ctx, in, 0, FoldFn[int, int](func(a, v int) int { return a + v }))
+	if got := Collect(context.Background(), red).Must(); len(got) != 0 {
+		t.Fatalf("got %v, want nothing", got)
+	}
+	if err := <-redErrc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("err=%v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,375 @@
+package v2
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock abstracts time so time-dependent helpers can be tested deterministically.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) ClockTicker
+	Sleep(d time.Duration)
+}
+
+// Timer is the subset of *time.Timer used by channel helpers.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// ClockTicker is the subset of *time.Ticker used by channel helpers.
+type ClockTicker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// WithClock sets the clock used by time-dependent channel helpers (Batch, Window, Ticker, ...).
+// A nil clock selects the real clock.
+func WithClock(c Clock) ChanOption {
+	return func(cfg *chanConfig) {
+		if cfg == nil {
+			return
+		}
+		cfg.clock = c
+		cfg.needs(featClock, "WithClock")
+	}
+}
+
+// RealClock returns the Clock backed by the time package.
+func RealClock() Clock { return realClock{} }
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTimer(d time.Duration) Timer         { return realTimer{time.NewTimer(d)} }
+func (realClock) NewTicker(d time.Duration) ClockTicker  { return realTicker{time.NewTicker(d)} }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time        { return t.t.C }
+func (t realTimer) Stop() bool                 { return t.t.Stop() }
+func (t realTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+type realTicker struct{ t *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time   { return t.t.C }
+func (t realTicker) Stop()                 { t.t.Stop() }
+func (t realTicker) Reset(d time.Duration) { t.t.Reset(d) }
+
+// FakeClock is a Clock that only moves when Advance is called. It is safe for concurrent use.
+//
+// Timers, tickers, After and Sleep register waiters; BlockUntil lets a test wait until the
+// code under test has registered them before advancing time.
+type FakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+	created int
+}
+
+type fakeWaiter struct {
+	c        *FakeClock
+	ch       chan time.Time
+	deadline time.Time
+	period   time.Duration // > 0 for tickers
+	active   bool
+}
+
+// NewFakeClock creates a FakeClock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the fake time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) add(d, period time.Duration) *fakeWaiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &fakeWaiter{c: c, ch: make(chan time.Time, 1), deadline: c.now.Add(d), period: period, active: true}
+	c.created++
+	if d <= 0 && period <= 0 {
+		w.active = false
+		w.ch <- c.now
+	} else {
+		c.waiters = append(c.waiters, w)
+	}
+	c.cond.Broadcast()
+	return w
+}
+
+// After returns a channel that receives the fake time once it has advanced by d.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time { return c.add(d, 0).ch }
+
+// NewTimer creates a timer firing once the fake time has advanced by d.
+func (c *FakeClock) NewTimer(d time.Duration) Timer { return c.add(d, 0) }
+
+// NewTicker creates a ticker firing every d of fake time. It panics if d <= 0, like time.NewTicker.
+func (c *FakeClock) NewTicker(d time.Duration) ClockTicker {
+	if d <= 0 {
+		panic("lambda/v2: non-positive interval for FakeClock.NewTicker")
+	}
+	return fakeTicker{c.add(d, d)}
+}
+
+// Sleep blocks until the fake time has advanced by d.
+func (c *FakeClock) Sleep(d time.Duration) { <-c.After(d) }
+
+// Advance moves the fake time forward by d and fires every timer and ticker that became due,
+// in deadline order.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	end := c.now.Add(d)
+	for {
+		due := c.due(end)
+		if len(due) == 0 {
+			break
+		}
+		sort.SliceStable(due, func(i, j int) bool { return due[i].deadline.Before(due[j].deadline) })
+		w := due[0]
+		if w.deadline.After(c.now) {
+			c.now = w.deadline
+		}
+		w.fire(c.now)
+	}
+	c.now = end
+	c.prune()
+	c.cond.Broadcast()
+}
+
+func (c *FakeClock) due(end time.Time) []*fakeWaiter {
+	var due []*fakeWaiter
+	for _, w := range c.waiters {
+		if w.active && !w.deadline.After(end) {
+			due = append(due, w)
+		}
+	}
+	return due
+}
+
+func (c *FakeClock) prune() {
+	kept := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.active {
+			kept = append(kept, w)
+		}
+	}
+	for i := len(kept); i < len(c.waiters); i++ {
+		c.waiters[i] = nil
+	}
+	c.waiters = kept
+}
+
+// BlockUntil blocks until at least n timers, tickers or sleepers are waiting on the clock.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.active() < n {
+		c.cond.Wait()
+	}
+}
+
+func (c *FakeClock) active() int {
+	n := 0
+	for _, w := range c.waiters {
+		if w.active {
+			n++
+		}
+	}
+	return n
+}
+
+// fire delivers now to w. Ticks are dropped if the receiver is behind, as with time.Ticker.
+func (w *fakeWaiter) fire(now time.Time) {
+	select {
+	case w.ch <- now:
+	default:
+	}
+	if w.period > 0 {
+		w.deadline = w.deadline.Add(w.period)
+		return
+	}
+	w.active = false
+}
+
+func (w *fakeWaiter) C() <-chan time.Time { return w.ch }
+
+func (w *fakeWaiter) Stop() bool {
+	w.c.mu.Lock()
+	defer w.c.mu.Unlock()
+	was := w.active
+	w.active = false
+	w.c.prune()
+	w.c.cond.Broadcast()
+	return was
+}
+
+func (w *fakeWaiter) Reset(d time.Duration) bool {
+	w.c.mu.Lock()
+	defer w.c.mu.Unlock()
+	was := w.active
+	w.deadline = w.c.now.Add(d)
+	if d <= 0 && w.period <= 0 {
+		if was {
+			w.active = false
+			w.c.prune()
+		}
+		w.fire(w.c.now)
+		w.c.cond.Broadcast()
+		return was
+	}
+	if !was {
+		w.c.waiters = append(w.c.waiters, w)
+	}
+	w.active = true
+	if w.period > 0 {
+		w.period = d
+	}
+	w.c.cond.Broadcast()
+	return was
+}
+
+type fakeTicker struct{ w *fakeWaiter }
+
+func (t fakeTicker) C() <-chan time.Time { return t.w.ch }
+func (t fakeTicker) Stop()               { t.w.Stop() }
+
+func (t fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("lambda/v2: non-positive interval for FakeClock ticker Reset")
+	}
+	t.w.Reset(d)
+}
+
+// Ticker emits the current time every d until ctx is done (see WithClock).
+func Ticker(ctx context.Context, d time.Duration, opts ...ChanOption) (<-chan time.Time, <-chan error) {
+	if d <= 0 {
+		return closedErrStream[time.Time](errInvalidInterval)
+	}
+	cfg, err := chanCfg(opts, featClock)
+	if err != nil {
+		return closedErrStream[time.Time](err)
+	}
+	out := make(chan time.Time, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Ticker", errc)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		t := cfg.clock.NewTicker(d)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case now := <-t.C():
+				select {
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				case out <- now:
+				}
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+// Interval emits 0, 1, 2, ... every d until ctx is done (see WithClock).
+func Interval(ctx context.Context, d time.Duration, opts ...ChanOption) (<-chan int, <-chan error) {
+	if d <= 0 {
+		return closedErrStream[int](errInvalidInterval)
+	}
+	cfg, err := chanCfg(opts, featClock)
+	if err != nil {
+		return closedErrStream[int](err)
+	}
+	out := make(chan int, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Interval", errc)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		t := cfg.clock.NewTicker(d)
+		defer t.Stop()
+		for i := 0; ; i++ {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case <-t.C():
+				select {
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				case out <- i:
+				}
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+// After emits the current time once after d, then closes (see WithClock).
+func After(ctx context.Context, d time.Duration, opts ...ChanOption) (<-chan time.Time, <-chan error) {
+	if d < 0 {
+		return closedErrStream[time.Time](errInvalidInterval)
+	}
+	cfg, err := chanCfg(opts, featClock)
+	if err != nil {
+		return closedErrStream[time.Time](err)
+	}
+	out := make(chan time.Time, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "After", errc)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		t := cfg.clock.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			errc <- ctx.Err()
+			return
+		case now := <-t.C():
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case out <- now:
+			}
+		}
+		errc <- nil
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
@@ -0,0 +1,215 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestClock() *FakeClock {
+	return NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+// waitTimers blocks until c has created n timers or tickers. Unlike BlockUntil, which counts
+// active waiters, this count only grows, so tests can tell a re-armed timer from a stale one.
+func waitTimers(t *testing.T, c *FakeClock, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		created := c.created
+		c.mu.Unlock()
+		if created >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d timers", n)
+}
+
+func TestFakeClock_TimerAndAfter(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	start := clock.Now()
+	timer := clock.NewTimer(time.Second)
+	after := clock.After(2 * time.Second)
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	clock.Advance(2 * time.Second)
+	if got := <-timer.C(); !got.Equal(start.Add(time.Second)) {
+		t.Fatalf("timer fired at %v", got)
+	}
+	if got := <-after; !got.Equal(start.Add(2 * time.Second)) {
+		t.Fatalf("after fired at %v", got)
+	}
+	if !clock.Now().Equal(start.Add(2500 * time.Millisecond)) {
+		t.Fatalf("now = %v", clock.Now())
+	}
+	if timer.Stop() {
+		t.Fatal("Stop on fired timer reported active")
+	}
+}
+
+func TestFakeClock_StopAndReset(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	timer := clock.NewTimer(time.Second)
+	if !timer.Stop() {
+		t.Fatal("Stop reported inactive")
+	}
+	clock.Advance(time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+
+	if timer.Reset(time.Second) {
+		t.Fatal("Reset on stopped timer reported active")
+	}
+	clock.Advance(time.Second)
+	<-timer.C()
+}
+
+func TestFakeClock_Ticker(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	start := clock.Now()
+	tk := clock.NewTicker(time.Second)
+	defer tk.Stop()
+
+	for i := 1; i <= 3; i++ {
+		clock.Advance(time.Second)
+		if got := <-tk.C(); !got.Equal(start.Add(time.Duration(i) * time.Second)) {
+			t.Fatalf("tick %d at %v", i, got)
+		}
+	}
+
+	// Ticks are dropped while the receiver is behind.
+	clock.Advance(5 * time.Second)
+	<-tk.C()
+	select {
+	case <-tk.C():
+		t.Fatal("expected dropped ticks")
+	default:
+	}
+}
+
+func TestFakeClock_BlockUntilSleep(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	done := make(chan struct{})
+	go func() {
+		clock.Sleep(time.Minute)
+		close(done)
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("sleeper not woken")
+	}
+}
+
+func TestTicker(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	start := clock.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	ticks, errc := Ticker(ctx, time.Second, WithClock(clock))
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	if got := <-ticks; !got.Equal(start.Add(time.Second)) {
+		t.Fatalf("tick at %v", got)
+	}
+	clock.Advance(time.Second)
+	if got := <-ticks; !got.Equal(start.Add(2 * time.Second)) {
+		t.Fatalf("tick at %v", got)
+	}
+
+	cancel()
+	Drain(nil, ticks)
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestInterval(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nums, errc := Interval(ctx, time.Second, WithClock(clock))
+
+	clock.BlockUntil(1)
+	var got []int
+	for len(got) < 3 {
+		clock.Advance(time.Second)
+		got = append(got, <-nums)
+	}
+	if !intsEqual(got, []int{0, 1, 2}) {
+		t.Fatalf("got %v", got)
+	}
+
+	cancel()
+	Drain(nil, nums)
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestAfter(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	start := clock.Now()
+	out, errc := After(context.Background(), time.Minute, WithClock(clock))
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	got := Collect(nil, out).Must()
+	mustErr(t, <-errc)
+	if len(got) != 1 || !got[0].Equal(start.Add(time.Minute)) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestTimeSources_InvalidInterval(t *testing.T) {
+	t.Parallel()
+
+	_, e1 := Ticker(nil, 0)
+	_, e2 := Interval(nil, -time.Second)
+	_, e3 := After(nil, -time.Second)
+	for i, errc := range []<-chan error{e1, e2, e3} {
+		if err := <-errc; !errors.Is(err, errInvalidInterval) {
+			t.Fatalf("%d: err = %v", i, err)
+		}
+	}
+}
+
+func TestWithClock_RejectedByUntimedHelpers(t *testing.T) {
+	t.Parallel()
+
+	in := make(chan int)
+	close(in)
+	_, errc := MapChan(nil, in, func(v int) int { return v }, WithClock(newTestClock()))
+	if err := <-errc; !errors.Is(err, errUnsupportedOption) {
+		t.Fatalf("err = %v", err)
+	}
+}
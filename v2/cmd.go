@@ -0,0 +1,307 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Command is a subprocess (or a pipeline of subprocesses) built by Cmd and Pipe.
+type Command struct {
+	stages []stage
+	stdin  io.Reader
+}
+
+type stage struct {
+	name string
+	args []string
+	env  []string // added to the inherited environment
+	dir  string
+}
+
+// Proc is a pipeline wrapper around Option[Command].
+type Proc struct{ Option[Command] }
+
+// CmdResult is the outcome of Proc.Run.
+type CmdResult struct {
+	Stdout   []byte
+	Stderr   []byte // of every stage, in pipeline order
+	ExitCode int
+}
+
+// ExitError is returned when a command exits with a non-zero status.
+type ExitError struct {
+	Name   string // the failing command
+	Code   int    // -1 if it was killed by a signal
+	Stderr []byte
+
+	err *exec.ExitError
+}
+
+func (e *ExitError) Error() string {
+	msg := fmt.Sprintf("lambda/v2: %s: %v", e.Name, e.err)
+	if s := strings.TrimSpace(string(e.Stderr)); s != "" {
+		if i := strings.IndexByte(s, '\n'); i >= 0 {
+			s = s[:i]
+		}
+		msg += ": " + s
+	}
+	return msg
+}
+
+// Unwrap returns the underlying *exec.ExitError.
+func (e *ExitError) Unwrap() error { return e.err }
+
+// Cmd creates a command builder. The command inherits the environment and working directory of
+// the current process unless WithEnv or WithDir say otherwise.
+func Cmd(name string, args ...string) Proc {
+	if name == "" {
+		return Proc{Err[Command](errors.New("lambda/v2: empty command name"))}
+	}
+	return Proc{Ok(Command{stages: []stage{{name: name, args: append([]string(nil), args...)}}})}
+}
+
+// Pipe connects the commands like a shell pipeline: the stdout of each command is the stdin of
+// the next. Only the first command may have stdin set. Each command keeps its own environment
+// and directory.
+func Pipe(cmds ...Proc) Proc {
+	if len(cmds) == 0 {
+		return Proc{Err[Command](errors.New("lambda/v2: empty pipeline"))}
+	}
+	var c Command
+	for i, p := range cmds {
+		if p.err != nil {
+			return p
+		}
+		if i > 0 && p.v.stdin != nil {
+			return Proc{Err[Command](fmt.Errorf("lambda/v2: Pipe: stdin set on command %d", i+1))}
+		}
+		c.stages = append(c.stages, p.v.stages...)
+	}
+	c.stdin = cmds[0].v.stdin
+	return Proc{Ok(c)}
+}
+
+// eachStage returns a copy of p with f applied to every stage.
+func (p Proc) eachStage(f func(*stage)) Proc {
+	if p.err != nil {
+		return p
+	}
+	c := p.v
+	c.stages = append([]stage(nil), c.stages...)
+	for i := range c.stages {
+		f(&c.stages[i])
+	}
+	return Proc{Ok(c)}
+}
+
+// WithEnv sets an environment variable (on every command of a pipeline).
+func (p Proc) WithEnv(key, value string) Proc {
+	return p.eachStage(func(s *stage) {
+		s.env = append(append([]string(nil), s.env...), key+"="+value)
+	})
+}
+
+// WithDir sets the working directory (of every command of a pipeline).
+func (p Proc) WithDir(dir string) Proc {
+	return p.eachStage(func(s *stage) { s.dir = dir })
+}
+
+// WithStdin sets the stdin of the command (or of the first command of a pipeline).
+func (p Proc) WithStdin(r Reader) Proc {
+	if p.err != nil {
+		return p
+	}
+	if r.err != nil {
+		return Proc{Err[Command](r.err)}
+	}
+	c := p.v
+	c.stdin = r.v
+	return Proc{Ok(c)}
+}
+
+// WithStdinBytes feeds b to the stdin of the command (or of the first command of a pipeline).
+func (p Proc) WithStdinBytes(b Bytes) Proc {
+	if b.err != nil {
+		return p.WithStdin(Reader{Err[io.Reader](b.err)})
+	}
+	return p.WithStdin(Read(bytes.NewReader(b.v)))
+}
+
+// running is a started pipeline.
+type running struct {
+	cmds   []*exec.Cmd
+	stderr []*bytes.Buffer
+}
+
+// start starts every stage, with the stdout of the last one written to stdout.
+func (c Command) start(ctx context.Context, stdout io.Writer) (*running, error) {
+	r := &running{}
+	var pipes []*os.File // parent copies of the pipe ends, closed once the children have them
+	defer func() {
+		for _, f := range pipes {
+			f.Close()
+		}
+	}()
+
+	stdin := c.stdin
+	for i, s := range c.stages {
+		cmd := exec.CommandContext(ctx, s.name, s.args...)
+		cmd.Dir = s.dir
+		if s.env != nil {
+			cmd.Env = append(os.Environ(), s.env...)
+		}
+		cmd.Stdin = stdin
+		if i == len(c.stages)-1 {
+			cmd.Stdout = stdout
+		} else {
+			pr, pw, err := os.Pipe()
+			if err != nil {
+				r.abort()
+				return nil, err
+			}
+			pipes = append(pipes, pr, pw)
+			cmd.Stdout, stdin = pw, pr
+		}
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Start(); err != nil {
+			r.abort()
+			return nil, err
+		}
+		r.cmds = append(r.cmds, cmd)
+		r.stderr = append(r.stderr, &stderr)
+	}
+	return r, nil
+}
+
+// abort kills and reaps the started stages.
+func (r *running) abort() {
+	for _, cmd := range r.cmds {
+		cmd.Process.Kill()
+	}
+	for _, cmd := range r.cmds {
+		cmd.Wait()
+	}
+}
+
+// wait waits for every stage. Like a shell with pipefail set, the pipeline fails with the
+// rightmost non-zero exit status.
+func (r *running) wait() (code int, stderr []byte, err error) {
+	var exitErr *ExitError
+	var errs []error
+	for i, cmd := range r.cmds {
+		werr := cmd.Wait()
+		stderr = append(stderr, r.stderr[i].Bytes()...)
+		var ee *exec.ExitError
+		switch {
+		case errors.As(werr, &ee):
+			exitErr = &ExitError{Name: cmd.Args[0], Code: ee.ExitCode(), Stderr: r.stderr[i].Bytes(), err: ee}
+		case werr != nil:
+			errs = append(errs, werr)
+		}
+	}
+	if exitErr != nil {
+		code = exitErr.Code
+		errs = append(errs, exitErr)
+	}
+	return code, stderr, errors.Join(errs...)
+}
+
+// Run runs the command to completion and captures its output. On a non-zero exit the result is
+// still returned, together with an *ExitError.
+func (p Proc) Run(ctx context.Context) Option[CmdResult] {
+	if p.err != nil {
+		return Err[CmdResult](p.err)
+	}
+	ctx = ensureCtx(ctx)
+	var stdout bytes.Buffer
+	r, err := p.v.start(ctx, &stdout)
+	if err != nil {
+		return Err[CmdResult](err)
+	}
+	code, stderr, err := r.wait()
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	return Wrap(CmdResult{Stdout: stdout.Bytes(), Stderr: stderr, ExitCode: code}, err)
+}
+
+// Output runs the command and returns its stdout.
+func (p Proc) Output(ctx context.Context) Bytes {
+	res, err := p.Run(ctx).Get()
+	return Bytes{Wrap(res.Stdout, err)}
+}
+
+// Lines runs the command and emits its stdout line by line (see ReadLines for the options).
+// The error channel reports a failed exit once the output is consumed; cancelling ctx or an
+// error reading the output kills the command.
+func (p Proc) Lines(ctx context.Context, opts ...ChanOption) (<-chan string, <-chan error) {
+	if p.err != nil {
+		return closedErrStream[string](p.err)
+	}
+	cfg, err := chanCfg(opts, featLines)
+	if err != nil {
+		return closedErrStream[string](err)
+	}
+	out := make(chan string, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Proc.Lines", errc)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx, cancel := context.WithCancel(ensureCtx(ctx))
+		defer cancel()
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			errc <- err
+			return
+		}
+		defer pr.Close()
+		r, err := p.v.start(ctx, pw)
+		pw.Close()
+		if err != nil {
+			errc <- err
+			return
+		}
+
+		s := newLineSplitter(pr, cfg)
+		readErr := func() error {
+			for {
+				l, err := s.next()
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case out <- l.Text:
+				}
+			}
+		}()
+		if readErr != nil {
+			cancel()
+			pr.Close()
+			r.wait()
+			errc <- readErr
+			return
+		}
+		_, _, err = r.wait()
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		errc <- err
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
@@ -0,0 +1,122 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCmd_RunCapturesOutput(t *testing.T) {
+	t.Parallel()
+
+	res := Cmd("sh", "-c", `printf '%s|%s' "$GREETING" "$(pwd)"; echo oops >&2`).
+		WithEnv("GREETING", "hi").
+		WithDir("/").
+		Run(context.Background()).
+		Must()
+	if string(res.Stdout) != "hi|/" || string(res.Stderr) != "oops\n" || res.ExitCode != 0 {
+		t.Fatalf("got %+v", res)
+	}
+
+	got := Cmd("tr", "a-z", "A-Z").WithStdinBytes(BytesOf([]byte("shout"))).Output(context.Background()).String().Must()
+	if got != "SHOUT" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestCmd_ExitError(t *testing.T) {
+	t.Parallel()
+
+	res, err := Cmd("sh", "-c", "echo partial; echo 'bad input' >&2; exit 3").Run(context.Background()).Get()
+	var ee *ExitError
+	if !errors.As(err, &ee) || ee.Code != 3 || string(ee.Stderr) != "bad input\n" || ee.Name != "sh" {
+		t.Fatalf("err = %#v", err)
+	}
+	var xe *exec.ExitError
+	if !errors.As(err, &xe) || !strings.Contains(err.Error(), "bad input") {
+		t.Fatalf("err = %v", err)
+	}
+	if res.ExitCode != 3 || string(res.Stdout) != "partial\n" {
+		t.Fatalf("res = %+v", res)
+	}
+
+	if _, err := Cmd("lambda-no-such-command").Run(context.Background()).Get(); !errors.Is(err, exec.ErrNotFound) {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err := Cmd("").Run(context.Background()).Get(); err == nil {
+		t.Fatal("expected error for empty name")
+	}
+	readErr := errors.New("boom")
+	if _, err := Cmd("cat").WithStdin(Reader{Err[io.Reader](readErr)}).Run(context.Background()).Get(); !errors.Is(err, readErr) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestPipe(t *testing.T) {
+	t.Parallel()
+
+	got := Pipe(
+		Cmd("cat"),
+		Cmd("tr", "a-z", "A-Z"),
+		Cmd("sh", "-c", "sed 's/^/> /'"),
+	).WithStdin(StrOf("one\ntwo\n").Reader()).Output(context.Background()).String().Must()
+	if got != "> ONE\n> TWO\n" {
+		t.Fatalf("got %q", got)
+	}
+
+	// Like pipefail: the rightmost failing stage decides the error.
+	res, err := Pipe(
+		Cmd("sh", "-c", "echo first >&2; exit 2"),
+		Cmd("sh", "-c", "cat; echo second >&2; exit 5"),
+		Cmd("cat"),
+	).Run(context.Background()).Get()
+	var ee *ExitError
+	if !errors.As(err, &ee) || ee.Code != 5 || string(ee.Stderr) != "second\n" {
+		t.Fatalf("err = %v", err)
+	}
+	if res.ExitCode != 5 || string(res.Stderr) != "first\nsecond\n" {
+		t.Fatalf("res = %+v", res)
+	}
+
+	if _, err := Pipe().Run(context.Background()).Get(); err == nil {
+		t.Fatal("expected error for empty pipeline")
+	}
+	if _, err := Pipe(Cmd("cat"), Cmd("cat").WithStdinBytes(BytesOf([]byte("x")))).Run(context.Background()).Get(); err == nil {
+		t.Fatal("expected error for stdin on a later stage")
+	}
+}
+
+func TestCmd_Lines(t *testing.T) {
+	t.Parallel()
+
+	out, errc := Pipe(Cmd("printf", `a\r\nb\nc`), Cmd("tr", "a-z", "A-Z")).Lines(context.Background())
+	if got := Collect(nil, out).Must(); strings.Join(got, "|") != "A|B|C" {
+		t.Fatalf("got %q", got)
+	}
+	mustErr(t, <-errc)
+
+	out, errc = Cmd("sh", "-c", "echo x; exit 4").Lines(context.Background())
+	Collect(nil, out)
+	var ee *ExitError
+	if err := <-errc; !errors.As(err, &ee) || ee.Code != 4 {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestCmd_LinesCancelKillsProcess(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	out, errc := Cmd("sh", "-c", "while :; do echo y; done").Lines(ctx)
+	<-out
+	cancel()
+	for range out {
+	}
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v", err)
+	}
+	waitGoroutines(t, before)
+}
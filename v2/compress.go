@@ -0,0 +1,304 @@
+package v2
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/flate"
+	"compress/gzip"
+	"compress/lzw"
+	"compress/zlib"
+	"errors"
+	"io"
+	"math"
+)
+
+// ErrDecompressionLimit is returned when decompressed data grows beyond the allowed size.
+var ErrDecompressionLimit = errors.New("lambda/v2: decompressed data exceeds limit")
+
+// DefaultDecompressLimit caps the output of every decompressor (Gunzip, Unzlib, Inflate, Bunzip2,
+// UnLZW, Decompress) to guard against decompression bombs. Zero or negative disables the cap.
+// Use DecompressLimit to set a limit for a single call.
+var DefaultDecompressLimit int64 = 1 << 30
+
+// Compression is a compression format recognized by DetectCompression.
+type Compression int
+
+const (
+	CompressionNone Compression = iota
+	CompressionGzip
+	CompressionZlib
+	CompressionBzip2
+)
+
+func (c Compression) String() string {
+	switch c {
+	case CompressionGzip:
+		return "gzip"
+	case CompressionZlib:
+		return "zlib"
+	case CompressionBzip2:
+		return "bzip2"
+	default:
+		return "none"
+	}
+}
+
+// DetectCompression identifies gzip, zlib and bzip2 data by its magic bytes.
+// Raw flate and LZW streams have no header and are reported as CompressionNone. The zlib header
+// is only two bytes and some text matches it, so Decompress also checks that the data decodes.
+func DetectCompression(b []byte) Compression {
+	switch {
+	case len(b) >= 2 && b[0] == 0x1f && b[1] == 0x8b:
+		return CompressionGzip
+	case len(b) >= 4 && b[0] == 'B' && b[1] == 'Z' && b[2] == 'h' && b[3] >= '1' && b[3] <= '9':
+		return CompressionBzip2
+	case len(b) >= 2 && isZlibHeader(b[0], b[1]):
+		return CompressionZlib
+	default:
+		return CompressionNone
+	}
+}
+
+// isZlibHeader checks the zlib CMF and FLG bytes: deflate with a window of at most 32 KiB, a
+// valid check sum and no preset dictionary (which this package cannot supply anyway).
+func isZlibHeader(cmf, flg byte) bool {
+	return cmf&0x0f == 8 && cmf>>4 <= 7 && flg&0x20 == 0 && (uint16(cmf)<<8|uint16(flg))%31 == 0
+}
+
+// looksLikeZlib decodes the start of a stream with a plausible zlib header. A two-byte header
+// still matches about one text in a thousand, so data that fails to decode is not zlib.
+func looksLikeZlib(head []byte) bool {
+	zr, err := zlib.NewReader(bytes.NewReader(head))
+	if err != nil {
+		return false
+	}
+	_, err = zr.Read(make([]byte, 1))
+	return err == nil || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// decompressor wraps r with a reader that decompresses it.
+type decompressor func(r io.Reader) (io.Reader, error)
+
+func gunzipReader(r io.Reader) (io.Reader, error)  { return gzip.NewReader(r) }
+func unzlibReader(r io.Reader) (io.Reader, error)  { return zlib.NewReader(r) }
+func inflateReader(r io.Reader) (io.Reader, error) { return flate.NewReader(r), nil }
+func bunzip2Reader(r io.Reader) (io.Reader, error) { return bzip2.NewReader(r), nil }
+
+func unlzwReader(order lzw.Order, litWidth int) decompressor {
+	return func(r io.Reader) (io.Reader, error) { return lzw.NewReader(r, order, litWidth), nil }
+}
+
+// detectReader picks the decompressor from the first bytes of r; unknown data passes through.
+func detectReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(4)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	switch DetectCompression(head) {
+	case CompressionGzip:
+		return gunzipReader(br)
+	case CompressionZlib:
+		head, err := br.Peek(512)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if !looksLikeZlib(head) {
+			return br, nil
+		}
+		return unzlibReader(br)
+	case CompressionBzip2:
+		return bunzip2Reader(br)
+	default:
+		return br, nil
+	}
+}
+
+// capReader fails with ErrDecompressionLimit once more than left bytes are read.
+type capReader struct {
+	r    io.Reader
+	left int64
+}
+
+func (c *capReader) Read(p []byte) (int, error) {
+	if c.left < 0 {
+		return 0, ErrDecompressionLimit
+	}
+	// Read at most one byte past the limit, to tell whether it is exceeded.
+	if c.left < math.MaxInt64 && int64(len(p)) > c.left+1 {
+		p = p[:c.left+1]
+	}
+	n, err := c.r.Read(p)
+	c.left -= int64(n)
+	if c.left < 0 {
+		return n - 1, ErrDecompressionLimit
+	}
+	return n, err
+}
+
+// capped applies limit (no cap if <= 0) to r.
+func capped(r io.Reader, limit int64) io.Reader {
+	if limit <= 0 {
+		return r
+	}
+	return &capReader{r: r, left: limit}
+}
+
+// limited returns d with its output capped at limit.
+func (d decompressor) limited(limit int64) decompressor {
+	return func(r io.Reader) (io.Reader, error) {
+		dr, err := d(r)
+		if err != nil {
+			return nil, err
+		}
+		return capped(dr, limit), nil
+	}
+}
+
+// compress runs b through the writer built by newW.
+func (b Bytes) compress(newW func(io.Writer) (io.WriteCloser, error)) Bytes {
+	if b.err != nil {
+		return b
+	}
+	var buf bytes.Buffer
+	w, err := newW(&buf)
+	if err != nil {
+		return Bytes{Err[[]byte](err)}
+	}
+	_, err = w.Write(b.v)
+	if err = errors.Join(err, w.Close()); err != nil {
+		return Bytes{Err[[]byte](err)}
+	}
+	return Bytes{Ok(buf.Bytes())}
+}
+
+func (b Bytes) decompress(d decompressor, limit int64) Bytes {
+	if b.err != nil {
+		return b
+	}
+	r, err := d.limited(limit)(bytes.NewReader(b.v))
+	if err != nil {
+		return Bytes{Err[[]byte](err)}
+	}
+	return ReadAll(r)
+}
+
+// Gzip compresses bytes with gzip at the given level (e.g. gzip.DefaultCompression).
+func (b Bytes) Gzip(level int) Bytes {
+	return b.compress(func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriterLevel(w, level) })
+}
+
+// Gunzip decompresses gzip data.
+func (b Bytes) Gunzip() Bytes { return b.decompress(gunzipReader, DefaultDecompressLimit) }
+
+// Zlib compresses bytes with zlib at the given level.
+func (b Bytes) Zlib(level int) Bytes {
+	return b.compress(func(w io.Writer) (io.WriteCloser, error) { return zlib.NewWriterLevel(w, level) })
+}
+
+// Unzlib decompresses zlib data.
+func (b Bytes) Unzlib() Bytes { return b.decompress(unzlibReader, DefaultDecompressLimit) }
+
+// Flate compresses bytes as a raw DEFLATE stream at the given level.
+func (b Bytes) Flate(level int) Bytes {
+	return b.compress(func(w io.Writer) (io.WriteCloser, error) { return flate.NewWriter(w, level) })
+}
+
+// Inflate decompresses a raw DEFLATE stream.
+func (b Bytes) Inflate() Bytes { return b.decompress(inflateReader, DefaultDecompressLimit) }
+
+// Bunzip2 decompresses bzip2 data (the standard library has no bzip2 compressor).
+func (b Bytes) Bunzip2() Bytes { return b.decompress(bunzip2Reader, DefaultDecompressLimit) }
+
+// LZW compresses bytes with LZW; order and litWidth must match when decompressing.
+func (b Bytes) LZW(order lzw.Order, litWidth int) Bytes {
+	return b.compress(func(w io.Writer) (io.WriteCloser, error) { return lzw.NewWriter(w, order, litWidth), nil })
+}
+
+// UnLZW decompresses LZW data.
+func (b Bytes) UnLZW(order lzw.Order, litWidth int) Bytes {
+	return b.decompress(unlzwReader(order, litWidth), DefaultDecompressLimit)
+}
+
+// Decompress detects gzip, zlib or bzip2 data by its magic bytes and decompresses it.
+// Data in no recognized format is returned unchanged.
+func (b Bytes) Decompress() Bytes { return b.DecompressLimit(DefaultDecompressLimit) }
+
+// DecompressLimit is Decompress with its output capped at limit bytes (no cap if <= 0).
+func (b Bytes) DecompressLimit(limit int64) Bytes { return b.decompress(detectReader, limit) }
+
+func (r Reader) decompress(d decompressor, limit int64) Reader {
+	if r.err != nil {
+		return r
+	}
+	dr, err := d.limited(limit)(r.v)
+	return Reader{Wrap(dr, err)}
+}
+
+// Gunzip wraps the reader with a gzip decompressor.
+func (r Reader) Gunzip() Reader { return r.decompress(gunzipReader, DefaultDecompressLimit) }
+
+// Unzlib wraps the reader with a zlib decompressor.
+func (r Reader) Unzlib() Reader { return r.decompress(unzlibReader, DefaultDecompressLimit) }
+
+// Inflate wraps the reader with a raw DEFLATE decompressor.
+func (r Reader) Inflate() Reader { return r.decompress(inflateReader, DefaultDecompressLimit) }
+
+// Bunzip2 wraps the reader with a bzip2 decompressor.
+func (r Reader) Bunzip2() Reader { return r.decompress(bunzip2Reader, DefaultDecompressLimit) }
+
+// UnLZW wraps the reader with an LZW decompressor.
+func (r Reader) UnLZW(order lzw.Order, litWidth int) Reader {
+	return r.decompress(unlzwReader(order, litWidth), DefaultDecompressLimit)
+}
+
+// Decompress wraps the reader with a decompressor picked from its magic bytes.
+func (r Reader) Decompress() Reader { return r.DecompressLimit(DefaultDecompressLimit) }
+
+// DecompressLimit is Decompress with its output capped at limit bytes (no cap if <= 0).
+func (r Reader) DecompressLimit(limit int64) Reader { return r.decompress(detectReader, limit) }
+
+// Zlib compresses the stream with zlib at the given level.
+func (s ByteStream) Zlib(level int) ByteStream {
+	return s.pipe(func(w io.Writer) (io.WriteCloser, error) { return zlib.NewWriterLevel(w, level) })
+}
+
+// Unzlib decompresses a zlib stream.
+func (s ByteStream) Unzlib() ByteStream {
+	return s.Through(decompressor(unzlibReader).limited(DefaultDecompressLimit))
+}
+
+// Flate compresses the stream as raw DEFLATE at the given level.
+func (s ByteStream) Flate(level int) ByteStream {
+	return s.pipe(func(w io.Writer) (io.WriteCloser, error) { return flate.NewWriter(w, level) })
+}
+
+// Inflate decompresses a raw DEFLATE stream.
+func (s ByteStream) Inflate() ByteStream {
+	return s.Through(decompressor(inflateReader).limited(DefaultDecompressLimit))
+}
+
+// Bunzip2 decompresses a bzip2 stream.
+func (s ByteStream) Bunzip2() ByteStream {
+	return s.Through(decompressor(bunzip2Reader).limited(DefaultDecompressLimit))
+}
+
+// LZW compresses the stream with LZW.
+func (s ByteStream) LZW(order lzw.Order, litWidth int) ByteStream {
+	return s.pipe(func(w io.Writer) (io.WriteCloser, error) { return lzw.NewWriter(w, order, litWidth), nil })
+}
+
+// UnLZW decompresses an LZW stream.
+func (s ByteStream) UnLZW(order lzw.Order, litWidth int) ByteStream {
+	return s.Through(unlzwReader(order, litWidth).limited(DefaultDecompressLimit))
+}
+
+// Decompress decompresses a gzip, zlib or bzip2 stream detected by its magic bytes; other data
+// passes through unchanged.
+func (s ByteStream) Decompress() ByteStream { return s.DecompressLimit(DefaultDecompressLimit) }
+
+// DecompressLimit is Decompress with its output capped at limit bytes (no cap if <= 0).
+func (s ByteStream) DecompressLimit(limit int64) ByteStream {
+	return s.Through(decompressor(detectReader).limited(limit))
+}
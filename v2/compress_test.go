@@ -0,0 +1,121 @@
+package v2
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/lzw"
+	"compress/zlib"
+	"encoding/hex"
+	"errors"
+	"math"
+	"testing"
+)
+
+// bzip2Hello is "hello bzip2\n" x3, compressed with bzip2.
+const bzip2Hello = "425a68393141592653592ed29d8e000008d9800010400010001264c010200022bfd54034f508069a68c29e69d6d1496447c5dc914e14240bb4a76380"
+
+func TestBytes_CompressionRoundTrips(t *testing.T) {
+	t.Parallel()
+
+	data := bytes.Repeat([]byte("compress me "), 500)
+	cases := []struct {
+		name string
+		got  Bytes
+	}{
+		{"gzip", BytesOf(data).Gzip(gzip.BestCompression).Gunzip()},
+		{"zlib", BytesOf(data).Zlib(zlib.DefaultCompression).Unzlib()},
+		{"flate", BytesOf(data).Flate(flate.BestSpeed).Inflate()},
+		{"lzw", BytesOf(data).LZW(lzw.LSB, 8).UnLZW(lzw.LSB, 8)},
+		{"gzip reader", BytesOf(data).Gzip(gzip.BestSpeed).Reader().Gunzip().ReadAll()},
+		{"zlib stream", BytesOf(data).Stream().Zlib(zlib.BestSpeed).Unzlib().Slurp()},
+		{"flate stream", BytesOf(data).Stream().Flate(flate.BestSpeed).Inflate().Slurp()},
+		{"lzw stream", BytesOf(data).Stream().LZW(lzw.MSB, 8).UnLZW(lzw.MSB, 8).Slurp()},
+	}
+	for _, tc := range cases {
+		got, err := tc.got.Get()
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("%s: err=%v len=%d", tc.name, err, len(got))
+		}
+	}
+
+	if _, err := BytesOf(data).Gzip(42).Get(); err == nil {
+		t.Fatal("expected error for invalid level")
+	}
+}
+
+func TestBytes_Decompress(t *testing.T) {
+	t.Parallel()
+
+	bz, _ := hex.DecodeString(bzip2Hello)
+	want := bytes.Repeat([]byte("hello bzip2\n"), 3)
+	if got := BytesOf(bz).Bunzip2().Must(); !bytes.Equal(got, want) {
+		t.Fatalf("bunzip2: %q", got)
+	}
+
+	plain := []byte("plain text")
+	inputs := map[Compression][]byte{
+		CompressionGzip:  BytesOf(plain).Gzip(gzip.DefaultCompression).Must(),
+		CompressionZlib:  BytesOf(plain).Zlib(zlib.DefaultCompression).Must(),
+		CompressionBzip2: bz,
+		CompressionNone:  plain,
+	}
+	for format, in := range inputs {
+		if got := DetectCompression(in); got != format {
+			t.Errorf("detected %v, want %v", got, format)
+		}
+		expect := plain
+		if format == CompressionBzip2 {
+			expect = want
+		}
+		if got := BytesOf(in).Decompress().Must(); !bytes.Equal(got, expect) {
+			t.Errorf("%v: got %q", format, got)
+		}
+		if got := BytesOf(in).Reader().Decompress().ReadAll().Must(); !bytes.Equal(got, expect) {
+			t.Errorf("%v reader: got %q", format, got)
+		}
+	}
+}
+
+func TestDecompress_PlainTextPassesThrough(t *testing.T) {
+	t.Parallel()
+
+	// Each starts with bytes a naive zlib header check accepts ("HK" and "x^" even have a
+	// valid check sum and no preset dictionary).
+	for _, text := range []string{"800 items\n", "(4 apples)", "HKEY_LOCAL_MACHINE\\Software", "x^2 + y^2 = z^2"} {
+		in := []byte(text)
+		if got := BytesOf(in).Decompress().Must(); string(got) != text {
+			t.Errorf("%q: got %q", text, got)
+		}
+		if got := BytesOf(in).Reader().Decompress().ReadAll().Must(); string(got) != text {
+			t.Errorf("%q reader: got %q", text, got)
+		}
+		if got := BytesOf(in).Stream().Decompress().Slurp().Must(); string(got) != text {
+			t.Errorf("%q stream: got %q", text, got)
+		}
+	}
+	if DetectCompression([]byte("800 items")) != CompressionNone || DetectCompression([]byte("(4 apples)")) != CompressionNone {
+		t.Error("zlib header with a preset dictionary detected")
+	}
+}
+
+func TestDecompressLimit(t *testing.T) {
+	t.Parallel()
+
+	bomb := BytesOf(make([]byte, 1<<20)).Gzip(gzip.BestCompression).Must()
+	if len(bomb) > 4096 {
+		t.Fatalf("bomb is %d bytes", len(bomb))
+	}
+	if _, err := BytesOf(bomb).DecompressLimit(1 << 16).Get(); !errors.Is(err, ErrDecompressionLimit) {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err := BytesOf(bomb).Stream().DecompressLimit(1 << 16).Discard().Get(); !errors.Is(err, ErrDecompressionLimit) {
+		t.Fatalf("stream err = %v", err)
+	}
+	if got := BytesOf(bomb).DecompressLimit(1 << 20).Must(); len(got) != 1<<20 {
+		t.Fatalf("got %d bytes at exactly the limit", len(got))
+	}
+	if got := BytesOf(bomb).DecompressLimit(math.MaxInt64).Must(); len(got) != 1<<20 {
+		t.Fatalf("got %d bytes with a MaxInt64 limit", len(got))
+	}
+}
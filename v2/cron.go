@@ -0,0 +1,409 @@
+package v2
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CronSchedule is a parsed cron expression. See ParseCron.
+type CronSchedule struct {
+	sec, min, hour, dom, month, dow uint64
+	domAny, dowAny                  bool
+	loc                             *time.Location
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronSec   = cronField{name: "second", min: 0, max: 59}
+	cronMin   = cronField{name: "minute", min: 0, max: 59}
+	cronHour  = cronField{name: "hour", min: 0, max: 23}
+	cronDom   = cronField{name: "day of month", min: 1, max: 31}
+	cronMonth = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is accepted as Sunday and folded onto 0.
+	cronDow = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron expression.
+//
+// It accepts the standard 5 fields (minute hour day-of-month month day-of-week), an optional
+// leading seconds field (6 fields), and the macros @yearly, @annually, @monthly, @weekly,
+// @daily, @midnight and @hourly. Fields support *, ?, lists, ranges, steps and month/weekday
+// names. A "CRON_TZ=<zone> " (or "TZ=<zone> ") prefix evaluates the schedule in that
+// location; otherwise it is evaluated in the location of the time passed to Next.
+//
+// As in Vixie cron, a time matches if it matches either day field when both are restricted.
+func ParseCron(expr string) (*CronSchedule, error) {
+	spec := strings.TrimSpace(expr)
+	s := &CronSchedule{}
+
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		tz, rest, _ := strings.Cut(spec, " ")
+		_, name, _ := strings.Cut(tz, "=")
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("lambda/v2: cron %q: %w", expr, err)
+		}
+		s.loc = loc
+		spec = strings.TrimSpace(rest)
+	}
+	if m, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = m
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("lambda/v2: cron %q: expected 5 or 6 fields, got %d", expr, len(fields))
+	}
+
+	var err error
+	parse := func(f string, def cronField) uint64 {
+		if err != nil {
+			return 0
+		}
+		var bits uint64
+		bits, err = parseCronField(f, def)
+		if err != nil {
+			err = fmt.Errorf("lambda/v2: cron %q: %w", expr, err)
+		}
+		return bits
+	}
+	s.sec = parse(fields[0], cronSec)
+	s.min = parse(fields[1], cronMin)
+	s.hour = parse(fields[2], cronHour)
+	s.dom = parse(fields[3], cronDom)
+	s.month = parse(fields[4], cronMonth)
+	s.dow = parse(fields[5], cronDow)
+	if err != nil {
+		return nil, err
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domAny = fields[3] == "*" || fields[3] == "?"
+	s.dowAny = fields[5] == "*" || fields[5] == "?"
+	return s, nil
+}
+
+func parseCronField(f string, def cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(f, ",") {
+		lo, hi, step := def.min, def.max, 1
+
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepStr, def.name)
+			}
+			step = n
+		}
+		switch {
+		case rng == "*" || rng == "?":
+		default:
+			a, b, isRange := strings.Cut(rng, "-")
+			v, err := def.value(a)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			if isRange {
+				if hi, err = def.value(b); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = def.max
+			}
+		}
+		if lo > hi {
+			return 0, fmt.Errorf("invalid range %q in %s field", rng, def.name)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (def cronField) value(s string) (int, error) {
+	if v, ok := def.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < def.min || v > def.max {
+		return 0, fmt.Errorf("invalid value %q in %s field (want %d-%d)", s, def.name, def.min, def.max)
+	}
+	return v, nil
+}
+
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domOK := s.dom&(1<<uint(t.Day())) != 0
+	dowOK := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
+
+// Next returns the first time after t that matches the schedule, in t's location.
+// It returns the zero time if there is none within five years.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	orig := t.Location()
+	loc := s.loc
+	if loc == nil {
+		loc = orig
+	}
+	t = t.In(loc)
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	limit := t.Year() + 5
+
+wrap:
+	for t.Year() <= limit {
+		for s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			if t.Month() == time.January {
+				continue wrap
+			}
+		}
+		for !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			if t.Day() == 1 {
+				continue wrap
+			}
+		}
+		for s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			if t.Hour() == 0 {
+				continue wrap
+			}
+		}
+		for s.min&(1<<uint(t.Minute())) == 0 {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			if t.Minute() == 0 {
+				continue wrap
+			}
+		}
+		for s.sec&(1<<uint(t.Second())) == 0 {
+			t = t.Add(time.Second)
+			if t.Second() == 0 {
+				continue wrap
+			}
+		}
+		return t.In(orig)
+	}
+	return time.Time{}
+}
+
+// runCron calls tick with every scheduled time until tick returns false, ctx is done or
+// the schedule is exhausted. It returns ctx.Err() if it was interrupted.
+func runCron(ctx context.Context, clock Clock, sched *CronSchedule, tick func(at time.Time) bool) error {
+	var last time.Time
+	for {
+		now := clock.Now()
+		if now.Before(last) {
+			now = last
+		}
+		next := sched.Next(now)
+		if next.IsZero() {
+			return nil
+		}
+		timer := clock.NewTimer(next.Sub(clock.Now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C():
+		}
+		last = next
+		if !tick(next) {
+			return ctx.Err()
+		}
+	}
+}
+
+// Cron emits the scheduled times of expr (see ParseCron) until ctx is done.
+// Ticks missed while the consumer is busy are skipped.
+func Cron(ctx context.Context, expr string, opts ...ChanOption) (<-chan time.Time, <-chan error) {
+	sched, err := ParseCron(expr)
+	if err != nil {
+		return closedErrStream[time.Time](err)
+	}
+	cfg, err := chanCfg(opts, featClock)
+	if err != nil {
+		return closedErrStream[time.Time](err)
+	}
+	out := make(chan time.Time, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Cron", errc)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		errc <- runCron(ctx, cfg.clock, sched, func(at time.Time) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case out <- at:
+				return true
+			}
+		})
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+// OverlapPolicy decides what Schedule does when a run is due while the previous one is still running.
+type OverlapPolicy int
+
+const (
+	// OverlapSkip drops the due run (default).
+	OverlapSkip OverlapPolicy = iota
+	// OverlapQueue runs due runs one after another once the previous one finishes.
+	OverlapQueue
+	// OverlapAllow starts due runs right away, concurrently with running ones.
+	OverlapAllow
+)
+
+// WithOverlap sets the overlap policy used by Schedule.
+func WithOverlap(p OverlapPolicy) ChanOption {
+	return func(c *chanConfig) {
+		if c == nil {
+			return
+		}
+		c.overlap = p
+		c.needs(featSchedule, "WithOverlap")
+	}
+}
+
+// Schedule runs job at the times of expr (see ParseCron) until ctx is done and emits every
+// result. Job errors are emitted as Err results and do not stop the schedule.
+// See WithOverlap for runs that are due while a previous run is still going.
+func Schedule[T any](ctx context.Context, expr string, job func(context.Context) Option[T], opts ...ChanOption) (<-chan Option[T], <-chan error) {
+	if job == nil {
+		return closedErrStream[Option[T]](ErrNilFunc("Schedule"))
+	}
+	sched, err := ParseCron(expr)
+	if err != nil {
+		return closedErrStream[Option[T]](err)
+	}
+	cfg, err := chanCfg(opts, featClock, featSchedule)
+	if err != nil {
+		return closedErrStream[Option[T]](err)
+	}
+	out := make(chan Option[T], cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Schedule", errc)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		var wg sync.WaitGroup
+		run := func() {
+			res := job(ctx)
+			select {
+			case <-ctx.Done():
+			case out <- res:
+			}
+		}
+
+		var err error
+		switch cfg.overlap {
+		case OverlapAllow:
+			err = runCron(ctx, cfg.clock, sched, func(time.Time) bool {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					run()
+				}()
+				return true
+			})
+		default:
+			// A single worker runs jobs in order. Ticks bump the queue, unless the worker is
+			// busy and the policy is skip.
+			var (
+				mu     sync.Mutex
+				queued int
+				busy   bool
+				wake   = make(chan struct{}, 1)
+				stop   = make(chan struct{})
+			)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for ctx.Err() == nil {
+					mu.Lock()
+					if queued > 0 {
+						queued--
+						busy = true
+						mu.Unlock()
+						run()
+						mu.Lock()
+						busy = false
+						mu.Unlock()
+						continue
+					}
+					mu.Unlock()
+					select {
+					case <-ctx.Done():
+					case <-wake:
+					case <-stop:
+						mu.Lock()
+						n := queued
+						mu.Unlock()
+						if n == 0 {
+							return
+						}
+					}
+				}
+			}()
+			err = runCron(ctx, cfg.clock, sched, func(time.Time) bool {
+				mu.Lock()
+				if cfg.overlap == OverlapQueue || (!busy && queued == 0) {
+					queued++
+				}
+				mu.Unlock()
+				select {
+				case wake <- struct{}{}:
+				default:
+				}
+				return true
+			})
+			close(stop)
+		}
+		wg.Wait()
+		errc <- err
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
@@ -0,0 +1,244 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParseCron_Next(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2024, 3, 14, 10, 7, 30, 0, time.UTC) // Thursday
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, 3, 14, 10, 15, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"*/20 * * * * *", time.Date(2024, 3, 14, 10, 7, 40, 0, time.UTC)},
+		{"0 30 9 * * MON-FRI", time.Date(2024, 3, 15, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,20 * ?", time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either one matches.
+		{"0 0 13 * FRI", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 feb *", time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{"CRON_TZ=America/New_York 0 9 * * *", time.Date(2024, 3, 14, 13, 0, 0, 0, time.UTC)},
+		{"TZ=Asia/Tokyo 0 0 * * *", time.Date(2024, 3, 14, 15, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.expr, err)
+		}
+		if got := s.Next(base); !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("%q: Next = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"CRON_TZ=Nowhere/Special * * * * *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("%q: expected error", expr)
+		}
+	}
+}
+
+func TestParseCron_Exhausted(t *testing.T) {
+	t.Parallel()
+
+	s, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Next(time.Now()); !got.IsZero() {
+		t.Fatalf("Next = %v", got)
+	}
+}
+
+func TestCron(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	start := clock.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	ticks, errc := Cron(ctx, "*/10 * * * * *", WithClock(clock))
+
+	for i := 1; i <= 2; i++ {
+		waitTimers(t, clock, i)
+		clock.Advance(10 * time.Second)
+		if got := <-ticks; !got.Equal(start.Add(time.Duration(i) * 10 * time.Second)) {
+			t.Fatalf("tick %d at %v", i, got)
+		}
+	}
+
+	cancel()
+	Drain(nil, ticks)
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v", err)
+	}
+
+	_, errc = Cron(nil, "bogus")
+	if err := <-errc; err == nil {
+		t.Fatal("expected parse error")
+	}
+	// Cron only emits ticks; the overlap policy belongs to Schedule.
+	_, errc = Cron(nil, "* * * * * *", WithOverlap(OverlapQueue))
+	if err := <-errc; !errors.Is(err, errUnsupportedOption) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+// blockingJob returns a job that reports every start on started and finishes once release
+// receives a value.
+func blockingJob(started chan<- int, release <-chan struct{}) func(context.Context) Option[int] {
+	n := 0
+	return func(ctx context.Context) Option[int] {
+		n++
+		id := n
+		started <- id
+		select {
+		case <-ctx.Done():
+			return Err[int](ctx.Err())
+		case <-release:
+			return Ok(id)
+		}
+	}
+}
+
+func TestSchedule_Skip(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	started := make(chan int, 4)
+	release := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	out, errc := Schedule(ctx, "* * * * * *", blockingJob(started, release), WithClock(clock))
+
+	waitTimers(t, clock, 1)
+	clock.Advance(time.Second)
+	<-started
+	waitTimers(t, clock, 2)
+	clock.Advance(time.Second) // due while run 1 is busy: skipped
+	waitTimers(t, clock, 3)
+	release <- struct{}{}
+	if got := (<-out).Must(); got != 1 {
+		t.Fatalf("got %d", got)
+	}
+
+	clock.Advance(time.Second)
+	if id := <-started; id != 2 {
+		t.Fatalf("started %d", id)
+	}
+	release <- struct{}{}
+	if got := (<-out).Must(); got != 2 {
+		t.Fatalf("got %d", got)
+	}
+
+	cancel()
+	Drain(nil, out)
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestSchedule_Queue(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	started := make(chan int, 4)
+	release := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out, _ := Schedule(ctx, "* * * * * *", blockingJob(started, release), WithClock(clock), WithOverlap(OverlapQueue))
+
+	waitTimers(t, clock, 1)
+	clock.Advance(time.Second)
+	<-started
+	waitTimers(t, clock, 2)
+	clock.Advance(time.Second) // due while run 1 is busy: queued
+	waitTimers(t, clock, 3)
+	release <- struct{}{}
+	if got := (<-out).Must(); got != 1 {
+		t.Fatalf("got %d", got)
+	}
+	if id := <-started; id != 2 {
+		t.Fatalf("started %d", id)
+	}
+	release <- struct{}{}
+	if got := (<-out).Must(); got != 2 {
+		t.Fatalf("got %d", got)
+	}
+}
+
+func TestSchedule_Allow(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	started := make(chan int, 4)
+	release := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	out, errc := Schedule(ctx, "* * * * * *", blockingJob(started, release), WithClock(clock), WithOverlap(OverlapAllow))
+
+	waitTimers(t, clock, 1)
+	clock.Advance(time.Second)
+	<-started
+	waitTimers(t, clock, 2)
+	clock.Advance(time.Second) // runs concurrently with run 1
+	<-started
+
+	close(release)
+	sum := (<-out).Must() + (<-out).Must()
+	if sum != 3 {
+		t.Fatalf("sum = %d", sum)
+	}
+
+	cancel()
+	Drain(nil, out)
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestSchedule_JobErrors(t *testing.T) {
+	t.Parallel()
+
+	boom := errors.New("boom")
+	clock := newTestClock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out, _ := Schedule(ctx, "* * * * * *", func(context.Context) Option[int] { return Err[int](boom) }, WithClock(clock))
+
+	waitTimers(t, clock, 1)
+	clock.Advance(time.Second)
+	if err := (<-out).Err(); !errors.Is(err, boom) {
+		t.Fatalf("err = %v", err)
+	}
+
+	_, errc := Schedule[int](nil, "* * * * *", nil)
+	if err := <-errc; err == nil {
+		t.Fatal("expected error for nil job")
+	}
+}
@@ -0,0 +1,243 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ItemError is a per-item failure that keeps the item that failed.
+type ItemError[T any] struct {
+	Item T
+	Err  error
+}
+
+func (e *ItemError[T]) Error() string {
+	if e.Err == nil {
+		return "lambda/v2: item failed"
+	}
+	return e.Err.Error()
+}
+
+func (e *ItemError[T]) Unwrap() error { return e.Err }
+
+// FailedItem returns the item that failed. It lets DeadLetter record items of any type.
+func (e *ItemError[T]) FailedItem() any { return e.Item }
+
+// BudgetError is returned by SplitErrors once the error budget is exhausted.
+type BudgetError struct {
+	Failures int
+	Total    int
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("lambda/v2: error budget exceeded (%d of %d items failed)", e.Failures, e.Total)
+}
+
+// WithMaxFailures makes SplitErrors fail the stream after more than n failed items.
+func WithMaxFailures(n int) ChanOption {
+	return func(c *chanConfig) {
+		if c == nil {
+			return
+		}
+		c.maxFailures = n
+		c.needs(featBudget, "WithMaxFailures")
+	}
+}
+
+// WithMaxFailureRatio makes SplitErrors fail the stream once more than ratio (0..1) of the items
+// failed. The ratio is only checked after minItems items, so early failures don't abort the stream.
+func WithMaxFailureRatio(ratio float64, minItems int) ChanOption {
+	return func(c *chanConfig) {
+		if c == nil {
+			return
+		}
+		c.maxFailureRatio = ratio
+		c.minRatioItems = minItems
+		c.needs(featBudget, "WithMaxFailureRatio")
+	}
+}
+
+// TryChanEach applies f to every value read from in and emits the outcome per item.
+// Failures don't stop the stream; they are emitted as Err options holding an *ItemError[T].
+func TryChanEach[T, U any](ctx context.Context, in <-chan T, f TryFn[T, U], opts ...ChanOption) (<-chan Option[U], <-chan error) {
+	if in == nil {
+		return closedErrStream[Option[U]](errNilChan)
+	}
+	if f == nil {
+		return closedErrStream[Option[U]](ErrNilFunc("TryChanEach"))
+	}
+	cfg, err := chanCfg(opts)
+	if err != nil {
+		return closedErrStream[Option[U]](err)
+	}
+	out := make(chan Option[U], cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "TryChanEach", errc)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case v, ok := <-in:
+				if !ok {
+					errc <- nil
+					return
+				}
+				u, err := f(v)
+				o := Ok(u)
+				if err != nil {
+					o = Err[U](&ItemError[T]{Item: v, Err: err})
+				}
+				select {
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				case out <- o:
+				}
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+// SplitErrors routes Ok values to good and errors to bad (the dead-letter channel).
+// Both outputs must be consumed (concurrently, unless buffered).
+//
+// WithMaxFailures and WithMaxFailureRatio set an error budget; once it is exceeded the
+// stream stops and errc yields a *BudgetError.
+func SplitErrors[T any](ctx context.Context, in <-chan Option[T], opts ...ChanOption) (good <-chan T, bad <-chan error, errc <-chan error) {
+	fail := func(err error) (<-chan T, <-chan error, <-chan error) {
+		g := make(chan T)
+		close(g)
+		b := make(chan error)
+		close(b)
+		ec := make(chan error, 1)
+		ec <- err
+		close(ec)
+		return g, b, ec
+	}
+	if in == nil {
+		return fail(errNilChan)
+	}
+	cfg, err := chanCfg(opts, featBudget)
+	if err != nil {
+		return fail(err)
+	}
+
+	ctx = ensureCtx(ctx)
+
+	g := make(chan T, cfg.buffer)
+	b := make(chan error, cfg.buffer)
+	ec := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "SplitErrors", ec)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer close(g)
+		defer close(b)
+		defer close(ec)
+
+		total, failures := 0, 0
+		for {
+			select {
+			case <-ctx.Done():
+				ec <- ctx.Err()
+				return
+			case o, ok := <-in:
+				if !ok {
+					ec <- nil
+					return
+				}
+				total++
+				if o.err == nil {
+					select {
+					case <-ctx.Done():
+						ec <- ctx.Err()
+						return
+					case g <- o.v:
+					}
+					continue
+				}
+				failures++
+				select {
+				case <-ctx.Done():
+					ec <- ctx.Err()
+					return
+				case b <- o.err:
+				}
+				if cfg.maxFailures > 0 && failures > cfg.maxFailures {
+					ec <- &BudgetError{Failures: failures, Total: total}
+					return
+				}
+				if cfg.maxFailureRatio > 0 && total >= cfg.minRatioItems &&
+					float64(failures)/float64(total) > cfg.maxFailureRatio {
+					ec <- &BudgetError{Failures: failures, Total: total}
+					return
+				}
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter.output("good"), g), meterOut(cfg.meter.output("bad"), b), tracked
+}
+
+type deadLetterRecord struct {
+	Error string `json:"error"`
+	Item  any    `json:"item,omitempty"`
+}
+
+// DeadLetter writes every error read from in as one NDJSON line to w and returns the
+// number of records written. Items of *ItemError values are included under "item".
+func DeadLetter(ctx context.Context, in <-chan error, w io.Writer) Option[int] {
+	if in == nil {
+		return Err[int](errNilChan)
+	}
+	if w == nil {
+		return Err[int](errNilWriter)
+	}
+	ctx = ensureCtx(ctx)
+	enc := json.NewEncoder(w)
+	n := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return Wrap(n, ctx.Err())
+		case err, ok := <-in:
+			if !ok {
+				return Ok(n)
+			}
+			if err == nil {
+				continue
+			}
+			rec := deadLetterRecord{Error: err.Error()}
+			var fi interface{ FailedItem() any }
+			if errors.As(err, &fi) {
+				rec.Item = fi.FailedItem()
+			}
+			if encErr := enc.Encode(rec); encErr != nil {
+				// Fall back to the item's textual form if it can't be encoded as JSON.
+				var ue *json.UnsupportedTypeError
+				var uv *json.UnsupportedValueError
+				if !errors.As(encErr, &ue) && !errors.As(encErr, &uv) {
+					return Wrap(n, encErr)
+				}
+				rec.Item = fmt.Sprint(rec.Item)
+				if encErr := enc.Encode(rec); encErr != nil {
+					return Wrap(n, encErr)
+				}
+			}
+			n++
+		}
+	}
+}
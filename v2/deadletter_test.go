@@ -0,0 +1,125 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// parseAll runs in through TryChanEach and SplitErrors and returns SplitErrors' error.
+// Upstream stages are canceled once SplitErrors stops.
+func parseAll(in []string, opts ...ChanOption) (good []int, bad []error, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	src, srcErrc := FromSlice(ctx, in)
+	tried, triedErrc := TryChanEach(ctx, src, TryFn[string, int](strconv.Atoi))
+	g, b, splitErrc := SplitErrors(ctx, tried, opts...)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for e := range b {
+			bad = append(bad, e)
+		}
+	}()
+	good = Collect(ctx, g).Must()
+	wg.Wait()
+
+	err = <-splitErrc
+	cancel()
+	_ = JoinErr(srcErrc, triedErrc)
+	return good, bad, err
+}
+
+func TestSplitErrors(t *testing.T) {
+	t.Parallel()
+
+	good, bad, err := parseAll([]string{"1", "x", "3", "y"})
+	mustErr(t, err)
+	if !intsEqual(good, []int{1, 3}) {
+		t.Fatalf("good=%v", good)
+	}
+	if len(bad) != 2 {
+		t.Fatalf("bad=%v", bad)
+	}
+	var ie *ItemError[string]
+	if !errors.As(bad[0], &ie) || ie.Item != "x" {
+		t.Fatalf("bad[0]=%v, want ItemError for x", bad[0])
+	}
+	var numErr *strconv.NumError
+	if !errors.As(bad[1], &numErr) {
+		t.Fatalf("bad[1]=%v should unwrap to *strconv.NumError", bad[1])
+	}
+}
+
+func TestSplitErrors_MaxFailures(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := parseAll([]string{"a", "b", "1", "c", "2"}, WithMaxFailures(2))
+	var be *BudgetError
+	if !errors.As(err, &be) || be.Failures != 3 || be.Total != 4 {
+		t.Fatalf("err=%v, want budget error after 3 of 4", err)
+	}
+
+	// The budget is enforced by SplitErrors, not by TryChanEach.
+	in := make(chan string)
+	_, errc := TryChanEach(context.Background(), in, TryFn[string, int](strconv.Atoi), WithMaxFailures(2))
+	if err := <-errc; !errors.Is(err, errUnsupportedOption) {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestSplitErrors_MaxFailureRatio(t *testing.T) {
+	t.Parallel()
+
+	// The first failure is 100%, but the ratio is only checked from the 4th item on.
+	in := []string{"a", "1", "2", "3", "b", "c", "4"}
+	_, _, err := parseAll(in, WithMaxFailureRatio(0.4, 4))
+	var be *BudgetError
+	if !errors.As(err, &be) || be.Failures != 3 || be.Total != 6 {
+		t.Fatalf("err=%v, want budget error after 3 of 6", err)
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	t.Parallel()
+
+	errs := make(chan error, 3)
+	errs <- &ItemError[map[string]int]{Item: map[string]int{"a": 1}, Err: errors.New("bad a")}
+	errs <- errors.New("plain")
+	errs <- &ItemError[func()]{Item: func() {}, Err: errors.New("unencodable")}
+	close(errs)
+
+	var buf bytes.Buffer
+	n := DeadLetter(context.Background(), errs, &buf).Must()
+	if n != 3 {
+		t.Fatalf("n=%d, want 3", n)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("lines=%q", lines)
+	}
+	var rec struct {
+		Error string         `json:"error"`
+		Item  map[string]int `json:"item"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if rec.Error != "bad a" || rec.Item["a"] != 1 {
+		t.Fatalf("rec=%+v", rec)
+	}
+	if lines[1] != `{"error":"plain"}` {
+		t.Fatalf("line=%q", lines[1])
+	}
+	if !strings.Contains(lines[2], `"error":"unencodable"`) {
+		t.Fatalf("line=%q", lines[2])
+	}
+}
@@ -0,0 +1,269 @@
+package v2
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// OpenFS opens a file of fsys for reading (e.g. an embed.FS, os.DirFS or zip.Reader).
+func OpenFS(fsys fs.FS, name string) ReadCloser {
+	if fsys == nil {
+		return ReadCloser{Err[io.ReadCloser](errors.New("lambda/v2: nil fs"))}
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return ReadCloser{Err[io.ReadCloser](err)}
+	}
+	return ReadCloser{Ok[io.ReadCloser](f)}
+}
+
+// ReadFileFS reads a whole file of fsys.
+func ReadFileFS(fsys fs.FS, name string) Bytes {
+	if fsys == nil {
+		return Bytes{Err[[]byte](errors.New("lambda/v2: nil fs"))}
+	}
+	b, err := fs.ReadFile(fsys, name)
+	return Bytes{Wrap(b, err)}
+}
+
+// MatchGlob reports whether a slash-separated path matches pattern. Pattern segments use
+// path.Match syntax, and a "**" segment matches zero or more whole segments, so "**/*.go"
+// matches Go files at any depth. Malformed patterns match nothing.
+func MatchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pat, segs []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for len(pat) > 1 && pat[1] == "**" {
+				pat = pat[1:]
+			}
+			if len(pat) == 1 {
+				return true
+			}
+			for i := 0; i <= len(segs); i++ {
+				if matchSegments(pat[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pat[0], segs[0]); err != nil || !ok {
+			return false
+		}
+		pat, segs = pat[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
+
+// WalkFilter selects the entries emitted by Walk. Patterns are MatchGlob patterns matched
+// against paths relative to the walk root. The zero value emits every file.
+type WalkFilter struct {
+	// Include, if non-empty, emits only files matching at least one pattern.
+	Include []string
+	// Exclude skips files and whole directories matching any pattern.
+	Exclude []string
+	// IgnoreFile names per-directory ignore files in .gitignore syntax (e.g. ".gitignore"):
+	// "#" comments, "!" negation, a trailing "/" for directories only, and patterns with a
+	// slash anchored to the ignore file's directory.
+	IgnoreFile string
+	// Dirs also emits directories.
+	Dirs bool
+}
+
+// WalkEntry is a file or directory found by Walk.
+type WalkEntry struct {
+	fs.DirEntry
+	Path string // path within the walked fs.FS
+	Rel  string // path relative to the walk root
+
+	fsys fs.FS
+}
+
+// Open opens the entry for reading.
+func (e WalkEntry) Open() ReadCloser { return OpenFS(e.fsys, e.Path) }
+
+// Bytes reads the entry's contents.
+func (e WalkEntry) Bytes() Bytes { return ReadFileFS(e.fsys, e.Path) }
+
+type ignoreRule struct {
+	base    string // directory of the ignore file, relative to the walk root ("." for the root)
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+func (r ignoreRule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.base != "." {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		rel = rel[len(r.base)+1:]
+	}
+	return MatchGlob(r.pattern, rel)
+}
+
+func parseIgnore(r io.Reader, base string) ([]ignoreRule, error) {
+	var rules []ignoreRule
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			rule.negate, line = true, line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly, line = true, strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			line = strings.TrimPrefix(line, "/")
+		} else {
+			line = "**/" + line
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules, sc.Err()
+}
+
+// ignored applies rules in order; the last matching rule wins.
+func ignored(rules []ignoreRule, rel string, isDir bool) bool {
+	skip := false
+	for _, r := range rules {
+		if r.match(rel, isDir) {
+			skip = !r.negate
+		}
+	}
+	return skip
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if MatchGlob(p, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// validate reports malformed Include or Exclude patterns.
+func (f WalkFilter) validate() error {
+	for _, p := range append(append([]string(nil), f.Include...), f.Exclude...) {
+		if _, err := path.Match(strings.ReplaceAll(p, "**", "*"), ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkFS walks root in fsys and calls visit for every entry selected by filter. If missing is
+// set, directories that vanish during the walk are reported to it (by relative path) and
+// skipped instead of failing the walk.
+func walkFS(ctx context.Context, fsys fs.FS, root string, filter WalkFilter, visit func(WalkEntry) error, missing func(rel string)) error {
+	var rules []ignoreRule
+	return fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		rel := p
+		if root != "." {
+			rel = strings.TrimPrefix(strings.TrimPrefix(p, root), "/")
+			if rel == "" {
+				rel = "."
+			}
+		}
+		if err != nil {
+			if missing == nil || !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			missing(rel)
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if rel != "." {
+			if matchAny(filter.Exclude, rel) || ignored(rules, rel, d.IsDir()) {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+		}
+		if d.IsDir() {
+			if filter.IgnoreFile != "" {
+				f, err := fsys.Open(path.Join(p, filter.IgnoreFile))
+				if err == nil {
+					more, err := parseIgnore(f, rel)
+					f.Close()
+					if err != nil {
+						return err
+					}
+					rules = append(rules, more...)
+				} else if !errors.Is(err, fs.ErrNotExist) {
+					return err
+				}
+			}
+			if !filter.Dirs || rel == "." {
+				return nil
+			}
+		} else if len(filter.Include) > 0 && !matchAny(filter.Include, rel) {
+			return nil
+		}
+		return visit(WalkEntry{DirEntry: d, Path: p, Rel: rel, fsys: fsys})
+	})
+}
+
+// Walk emits the files below root in fsys (lexical order), filtered by filter.
+// Use os.DirFS to walk the local file system.
+func Walk(ctx context.Context, fsys fs.FS, root string, filter WalkFilter, opts ...ChanOption) (<-chan WalkEntry, <-chan error) {
+	if fsys == nil {
+		return closedErrStream[WalkEntry](errors.New("lambda/v2: nil fs"))
+	}
+	if err := filter.validate(); err != nil {
+		return closedErrStream[WalkEntry](err)
+	}
+	cfg, err := chanCfg(opts)
+	if err != nil {
+		return closedErrStream[WalkEntry](err)
+	}
+	out := make(chan WalkEntry, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Walk", errc)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		errc <- walkFS(ctx, fsys, root, filter, func(e WalkEntry) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case out <- e:
+				return nil
+			}
+		}, nil)
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
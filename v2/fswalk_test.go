@@ -0,0 +1,159 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func walkFixture() fstest.MapFS {
+	file := func(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+	return fstest.MapFS{
+		".gitignore":          file("*.log\nbuild/\n/secret.txt\n!keep.log\n"),
+		"main.go":             file("package main"),
+		"secret.txt":          file("x"),
+		"debug.log":           file("x"),
+		"keep.log":            file("kept"),
+		"build/out.go":        file("x"),
+		"pkg/a.go":            file("package pkg"),
+		"pkg/a_test.go":       file("package pkg"),
+		"pkg/secret.txt":      file("not anchored here"),
+		"pkg/deep/b.go":       file("package deep"),
+		"pkg/deep/.gitignore": file("gen_*.go\n"),
+		"pkg/deep/gen_x.go":   file("x"),
+		"vendor/dep/c.go":     file("x"),
+	}
+}
+
+func walkRels(t *testing.T, fsys fstest.MapFS, root string, filter WalkFilter) []string {
+	t.Helper()
+	out, errc := Walk(context.Background(), fsys, root, filter)
+	var rels []string
+	for _, e := range Collect(nil, out).Must() {
+		rels = append(rels, e.Rel)
+	}
+	mustErr(t, <-errc)
+	return rels
+}
+
+func TestMatchGlob(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "a/b/c.go", true},
+		{"a/**/c.go", "a/c.go", true},
+		{"a/**/c.go", "a/x/y/c.go", true},
+		{"a/**", "a/x/y", true},
+		{"*.go", "a/b.go", false},
+		{"a/*/c", "a/b/x/c", false},
+		{"[", "[", false},
+	}
+	for _, tc := range cases {
+		if got := MatchGlob(tc.pattern, tc.name); got != tc.want {
+			t.Errorf("MatchGlob(%q, %q) = %v", tc.pattern, tc.name, got)
+		}
+	}
+}
+
+func TestWalk_IgnoreIncludeExclude(t *testing.T) {
+	t.Parallel()
+
+	fsys := walkFixture()
+	got := walkRels(t, fsys, ".", WalkFilter{
+		IgnoreFile: ".gitignore",
+		Include:    []string{"**/*.go", "**/*.log", "**/*.txt"},
+		Exclude:    []string{"vendor", "**/*_test.go"},
+	})
+	want := []string{"keep.log", "main.go", "pkg/a.go", "pkg/deep/b.go", "pkg/secret.txt"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("got %v\nwant %v", got, want)
+	}
+
+	// Rel is relative to the root; directories are emitted on request.
+	got = walkRels(t, fsys, "pkg", WalkFilter{Dirs: true, Exclude: []string{"**/*.txt"}})
+	want = []string{"a.go", "a_test.go", "deep", "deep/.gitignore", "deep/b.go", "deep/gen_x.go"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("got %v\nwant %v", got, want)
+	}
+
+	if _, errc := Walk(context.Background(), fsys, ".", WalkFilter{Include: []string{"["}}); <-errc == nil {
+		t.Fatal("expected error for bad pattern")
+	}
+	if _, errc := Walk(context.Background(), fsys, "missing", WalkFilter{}); <-errc == nil {
+		t.Fatal("expected error for missing root")
+	}
+}
+
+func TestWalk_FeedsParTryChan(t *testing.T) {
+	t.Parallel()
+
+	fsys := walkFixture()
+	entries, werrc := Walk(context.Background(), fsys, "pkg", WalkFilter{Include: []string{"**/*.go"}})
+	pkgs, perrc := ParTryChan(context.Background(), entries, func(e WalkEntry) (string, error) {
+		b, err := e.Bytes().Get()
+		return e.Rel + ":" + string(b), err
+	}, WithConcurrency(3))
+	got := Collect(nil, pkgs).Must()
+	mustErr(t, JoinErr(werrc, perrc))
+	sort.Strings(got)
+	if len(got) != 4 || got[0] != "a.go:package pkg" {
+		t.Fatalf("got %v", got)
+	}
+
+	if s := OpenFS(fsys, "main.go").Slurp().String().Must(); s != "package main" {
+		t.Fatalf("OpenFS: %q", s)
+	}
+	if _, err := ReadFileFS(fsys, "nope").Get(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// vanishingFS reports a directory as gone when it is read, as if it was removed mid-walk.
+type vanishingFS struct {
+	fstest.MapFS
+	gone string
+}
+
+func (f vanishingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == f.gone {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return f.MapFS.ReadDir(name)
+}
+
+func TestWalkFS_VanishedDirectory(t *testing.T) {
+	t.Parallel()
+
+	fsys := vanishingFS{MapFS: walkFixture(), gone: "pkg"}
+	var seen, missing []string
+	visit := func(e WalkEntry) error {
+		seen = append(seen, e.Rel)
+		return nil
+	}
+	err := walkFS(context.Background(), fsys, ".", WalkFilter{}, visit, func(rel string) {
+		missing = append(missing, rel)
+	})
+	if err != nil || len(missing) != 1 || missing[0] != "pkg" {
+		t.Fatalf("err=%v missing=%v", err, missing)
+	}
+	for _, rel := range seen {
+		if strings.HasPrefix(rel, "pkg/") {
+			t.Fatalf("walked into vanished dir: %v", seen)
+		}
+	}
+	if seen[len(seen)-1] != "vendor/dep/c.go" {
+		t.Fatalf("walk stopped early: %v", seen)
+	}
+
+	if err := walkFS(context.Background(), fsys, ".", WalkFilter{}, visit, nil); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("err = %v", err)
+	}
+}
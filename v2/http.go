@@ -183,8 +183,13 @@ func (r Req) Do(ctx context.Context) Resp {
 	return Resp{Wrap(res, err)}
 }
 
-// Slurp reads the full response body and closes it.
-func (r Resp) Slurp() Bytes {
+// Slurp reads the full response body, up to DefaultReadLimit, and closes it.
+func (r Resp) Slurp() Bytes { return r.SlurpLimit(DefaultReadLimit) }
+
+// SlurpLimit reads the full response body and closes it, failing with ErrTooLarge if it is
+// longer than limit bytes (no limit if <= 0) or than the limit set with MaxBytes. A response
+// whose Content-Length exceeds the limit fails without reading the body.
+func (r Resp) SlurpLimit(limit int64) Bytes {
 	if r.err != nil {
 		return Bytes{Err[[]byte](r.err)}
 	}
@@ -194,7 +199,27 @@ func (r Resp) Slurp() Bytes {
 	if r.v.Body == nil {
 		return Bytes{Err[[]byte](errors.New("lambda/v2: nil response body"))}
 	}
-	return Slurp(r.v.Body)
+	if m, ok := r.v.Body.(maxBytesReadCloser); ok && (limit <= 0 || m.limit < limit) {
+		limit = m.limit
+	}
+	if limit > 0 && r.v.ContentLength > limit {
+		err := &ErrTooLarge{Limit: limit, Size: r.v.ContentLength}
+		return Bytes{Err[[]byte](errors.Join(err, r.v.Body.Close()))}
+	}
+	return SlurpLimit(r.v.Body, limit)
+}
+
+// MaxBytes makes reads of the response body fail with ErrTooLarge once more than n bytes have
+// been read; Slurp then also checks Content-Length against n. n <= 0 changes nothing.
+func (r Resp) MaxBytes(n int64) Resp {
+	if r.err != nil || n <= 0 {
+		return r
+	}
+	if r.v == nil || r.v.Body == nil {
+		return r
+	}
+	r.v.Body = ReadCloser{Ok(r.v.Body)}.MaxBytes(n).v
+	return r
 }
 
 // StatusCode returns the response status code.
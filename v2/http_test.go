@@ -3,6 +3,7 @@ package v2
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -79,3 +80,31 @@ func TestHTTP_JSONBody(t *testing.T) {
 }
 
 
+
+func TestHTTP_SlurpLimit(t *testing.T) {
+	body := strings.Repeat("x", 1000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chunked" {
+			w.(http.Flusher).Flush() // no Content-Length
+		}
+		_, _ = io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	var tl *ErrTooLarge
+	_, err := Get(srv.URL).Do(context.Background()).SlurpLimit(100).Get()
+	if !errors.As(err, &tl) || tl.Limit != 100 || tl.Size != 1000 {
+		t.Fatalf("err = %#v", err)
+	}
+	_, err = Get(srv.URL + "/chunked").Do(context.Background()).SlurpLimit(100).Get()
+	if !errors.As(err, &tl) || tl.Size != 101 {
+		t.Fatalf("err = %#v", err)
+	}
+	_, err = Get(srv.URL).Do(context.Background()).MaxBytes(10).Slurp().Get()
+	if !errors.As(err, &tl) || tl.Limit != 10 || tl.Size != 1000 {
+		t.Fatalf("err = %#v", err)
+	}
+	if got := Get(srv.URL).Do(context.Background()).SlurpLimit(1000).Must(); len(got) != 1000 {
+		t.Fatalf("got %d bytes", len(got))
+	}
+}
@@ -1,23 +1,47 @@
 package v2
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io"
+	"math"
 	"os"
 )
 
+// DefaultReadLimit caps how many bytes ReadAll, Slurp and the Slurp and ReadAll methods read into
+// memory; larger inputs fail with ErrTooLarge. Zero or negative (the default) means no limit.
+// Use ReadAllLimit or SlurpLimit to set a limit for a single call, or MaxBytes for a pipeline.
+var DefaultReadLimit int64
+
+// ErrTooLarge is returned when an input exceeds a read limit.
+type ErrTooLarge struct {
+	Limit int64
+	// Size is the size observed when reading stopped: the Content-Length of an HTTP response
+	// if it announced one, otherwise the number of bytes read (at least Limit+1).
+	Size int64
+}
+
+func (e *ErrTooLarge) Error() string {
+	return fmt.Sprintf("lambda/v2: input too large: %d bytes exceeds limit of %d", e.Size, e.Limit)
+}
+
 // Open opens a file for reading.
 func Open(path string) ReadCloser {
 	f, err := os.Open(path)
-	var rc io.ReadCloser = f
-	return ReadCloser{Wrap(rc, err)}
+	if err != nil {
+		return ReadCloser{Err[io.ReadCloser](err)}
+	}
+	return ReadCloser{Ok[io.ReadCloser](f)}
 }
 
 // Create creates/truncates a file for writing.
 func Create(path string) WriteCloser {
 	f, err := os.Create(path)
-	var wc io.WriteCloser = f
-	return WriteCloser{Wrap(wc, err)}
+	if err != nil {
+		return WriteCloser{Err[io.WriteCloser](err)}
+	}
+	return WriteCloser{Ok[io.WriteCloser](f)}
 }
 
 // Read wraps an io.Reader for pipeline operations.
@@ -26,15 +50,227 @@ func Read(r io.Reader) Reader {
 	return Reader{Ok(rr)}
 }
 
-// ReadAll reads all content from r (no Close).
-func ReadAll(r io.Reader) Bytes {
-	b, err := io.ReadAll(r)
+// ReadAll reads all content from r (no Close), up to DefaultReadLimit.
+func ReadAll(r io.Reader) Bytes { return ReadAllLimit(r, DefaultReadLimit) }
+
+// ReadAllLimit reads all content from r (no Close), failing with ErrTooLarge if it is longer
+// than limit bytes (no limit if <= 0).
+func ReadAllLimit(r io.Reader, limit int64) Bytes {
+	b, err := readAllLimit(r, limit)
 	return Bytes{Wrap(b, err)}
 }
 
-// Slurp reads all content from r and closes it.
-func Slurp(r io.ReadCloser) Bytes {
-	b, readErr := io.ReadAll(r)
+// Slurp reads all content from r, up to DefaultReadLimit, and closes it.
+func Slurp(r io.ReadCloser) Bytes { return SlurpLimit(r, DefaultReadLimit) }
+
+// SlurpLimit reads all content from r and closes it, failing with ErrTooLarge if it is longer
+// than limit bytes (no limit if <= 0).
+func SlurpLimit(r io.ReadCloser, limit int64) Bytes {
+	b, readErr := readAllLimit(r, limit)
 	closeErr := r.Close()
 	return Bytes{Wrap(b, errors.Join(readErr, closeErr))}
 }
+
+func readAllLimit(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 || limit == math.MaxInt64 {
+		return io.ReadAll(r) // nothing can exceed MaxInt64 bytes
+	}
+	b, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return b, err
+	}
+	if int64(len(b)) > limit {
+		return nil, &ErrTooLarge{Limit: limit, Size: int64(len(b))}
+	}
+	return b, nil
+}
+
+// maxBytesReader fails with ErrTooLarge once more than limit bytes are read.
+type maxBytesReader struct {
+	r     io.Reader
+	limit int64
+	n     int64
+}
+
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.n > m.limit {
+		return 0, &ErrTooLarge{Limit: m.limit, Size: m.n}
+	}
+	// Read at most one byte past the limit, to tell whether it is exceeded.
+	if rem := m.limit - m.n; rem < math.MaxInt64 && int64(len(p)) > rem+1 {
+		p = p[:rem+1]
+	}
+	n, err := m.r.Read(p)
+	m.n += int64(n)
+	if m.n > m.limit {
+		return n - 1, &ErrTooLarge{Limit: m.limit, Size: m.n}
+	}
+	return n, err
+}
+
+type maxBytesReadCloser struct {
+	*maxBytesReader
+	io.Closer
+}
+
+// countingWriter counts the bytes written through the WriteCloser methods; it is stored in the
+// Option so that Close can report the total.
+type countingWriter struct {
+	io.WriteCloser
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.WriteCloser.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ReadFrom keeps io.Copy able to use the io.ReaderFrom of the underlying writer (e.g. *os.File).
+func (c *countingWriter) ReadFrom(r io.Reader) (int64, error) {
+	n, err := io.Copy(c.WriteCloser, r)
+	c.n += n
+	return n, err
+}
+
+// counting returns w with its writer wrapped in a countingWriter.
+func (w WriteCloser) counting() (WriteCloser, *countingWriter) {
+	if c, ok := w.v.(*countingWriter); ok {
+		return w, c
+	}
+	c := &countingWriter{WriteCloser: w.v}
+	return WriteCloser{Wrap[io.WriteCloser](c, w.err)}, c
+}
+
+// writer returns the caller's writer, without the countingWriter.
+func (w WriteCloser) writer() io.WriteCloser {
+	if c, ok := w.v.(*countingWriter); ok {
+		return c.WriteCloser
+	}
+	return w.v
+}
+
+// Get returns the contained writer and error. Write, WriteString and Copy don't change the
+// writer that is returned.
+func (w WriteCloser) Get() (io.WriteCloser, error) { return w.writer(), w.err }
+
+// Must returns the contained writer or panics if the WriteCloser contains an error.
+func (w WriteCloser) Must() io.WriteCloser { return Wrap(w.writer(), w.err).Must() }
+
+// failed records err; the writer is kept so that Close can still close it.
+func (w WriteCloser) failed(err error) WriteCloser {
+	if err != nil {
+		w.Option = Wrap(w.v, err)
+	}
+	return w
+}
+
+// Write writes the contained bytes. An error in b is carried forward.
+func (w WriteCloser) Write(b Bytes) WriteCloser {
+	if w.err != nil {
+		return w
+	}
+	w, c := w.counting()
+	if b.err != nil {
+		return w.failed(b.err)
+	}
+	_, err := c.Write(b.v)
+	return w.failed(err)
+}
+
+// WriteString writes s.
+func (w WriteCloser) WriteString(s string) WriteCloser {
+	if w.err != nil {
+		return w
+	}
+	w, c := w.counting()
+	_, err := io.WriteString(c, s)
+	return w.failed(err)
+}
+
+// Copy copies everything from r. An error in r is carried forward.
+func (w WriteCloser) Copy(from Reader) WriteCloser {
+	if w.err != nil {
+		return w
+	}
+	w, c := w.counting()
+	if from.err != nil {
+		return w.failed(from.err)
+	}
+	_, err := io.Copy(c, from.v)
+	return w.failed(err)
+}
+
+// Close closes the contained writer and returns the number of bytes written. After an earlier
+// error, writers with an Abort method (such as *AtomicFile) are aborted instead, so nothing is
+// committed; other writers are still closed. The earlier error is joined with the close or
+// abort error.
+func (w WriteCloser) Close() Option[int64] {
+	if w.v == nil {
+		return Err[int64](w.err)
+	}
+	var n int64
+	if c, ok := w.v.(*countingWriter); ok {
+		n = c.n
+	}
+	wc := w.writer()
+	if a, ok := wc.(interface{ Abort() error }); ok && w.err != nil {
+		return Wrap(n, errors.Join(w.err, a.Abort()))
+	}
+	return Wrap(n, errors.Join(w.err, wc.Close()))
+}
+
+// Close closes the contained io.ReadCloser. It returns nil if there is nothing to close.
+func (r ReadCloser) Close() error {
+	if r.v == nil {
+		return nil
+	}
+	return r.v.Close()
+}
+
+// Limit stops reading after n bytes, like io.LimitReader.
+func (r Reader) Limit(n int64) Reader {
+	if r.err != nil {
+		return r
+	}
+	return Reader{Ok(io.LimitReader(r.v, n))}
+}
+
+// MaxBytes makes every later read of the pipeline fail with ErrTooLarge once more than n bytes
+// have been read, unlike Limit which silently stops. n <= 0 leaves the reader unchanged.
+func (r Reader) MaxBytes(n int64) Reader {
+	if r.err != nil || n <= 0 {
+		return r
+	}
+	return Reader{Ok[io.Reader](&maxBytesReader{r: r.v, limit: n})}
+}
+
+// MaxBytes makes every later read of the pipeline fail with ErrTooLarge once more than n bytes
+// have been read. n <= 0 leaves the reader unchanged.
+func (r ReadCloser) MaxBytes(n int64) ReadCloser {
+	if r.err != nil || n <= 0 {
+		return r
+	}
+	return ReadCloser{Ok[io.ReadCloser](maxBytesReadCloser{&maxBytesReader{r: r.v, limit: n}, r.v})}
+}
+
+// TeeTo copies everything read from the reader to w.
+func (r Reader) TeeTo(w io.Writer) Reader {
+	if r.err != nil {
+		return r
+	}
+	if w == nil {
+		return Reader{Err[io.Reader](errNilWriter)}
+	}
+	return Reader{Ok(io.TeeReader(r.v, w))}
+}
+
+// Lines emits the lines of the reader (without line endings) on a channel. Like Str.Lines,
+// lines are limited to 1 MiB unless configured with WithMaxLineLength and WithLongLines;
+// use ReadLines for line numbers and offsets.
+func (r Reader) Lines(ctx context.Context, opts ...ChanOption) (<-chan string, <-chan error) {
+	if r.err != nil {
+		return closedErrStream[string](r.err)
+	}
+	return readLines(ctx, "Lines", r.v, opts, func(l Line) string { return l.Text })
+}
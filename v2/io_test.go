@@ -2,8 +2,12 @@ package v2
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
+	"math"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -46,3 +50,156 @@ func TestOptionReader_ReadAll(t *testing.T) {
 		t.Fatalf("unexpected")
 	}
 }
+
+type testWriteCloser struct {
+	bytes.Buffer
+	closed   bool
+	closeErr error
+}
+
+func (t *testWriteCloser) Close() error {
+	t.closed = true
+	return t.closeErr
+}
+
+func TestWriteCloser_JSONToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.json")
+	n := Create(path).
+		Write(Ok(map[string]int{"a": 1}).ToJSON()).
+		Copy(StrOf("\n").Reader()).
+		Close().
+		Must()
+
+	b, err := os.ReadFile(path)
+	if err != nil || string(b) != "{\"a\":1}\n" || n != int64(len(b)) {
+		t.Fatalf("n=%d content=%q err=%v", n, b, err)
+	}
+}
+
+func TestWriteCloser_ClosesAfterError(t *testing.T) {
+	sentinel := errors.New("upstream failed")
+	closeErr := errors.New("close failed")
+	wc := &testWriteCloser{closeErr: closeErr}
+	o := WriteCloser{Ok[io.WriteCloser](wc)}.
+		WriteString("kept").
+		Write(Bytes{Err[[]byte](sentinel)}).
+		WriteString("skipped").
+		Close()
+	if !wc.closed || wc.String() != "kept" {
+		t.Fatalf("closed=%v content=%q", wc.closed, wc.String())
+	}
+	if n, err := o.Get(); n != 4 || !errors.Is(err, sentinel) || !errors.Is(err, closeErr) {
+		t.Fatalf("n=%d err=%v", n, err)
+	}
+
+	w := WriteCloser{Ok[io.WriteCloser](wc)}.WriteString("x")
+	if got, _ := w.Get(); got != io.WriteCloser(wc) || w.Must() != io.WriteCloser(wc) {
+		t.Fatalf("Get() = %T, want the original writer", got)
+	}
+
+	if _, err := Create(filepath.Join(t.TempDir(), "missing", "x")).WriteString("x").Close().Get(); err == nil {
+		t.Fatal("expected create error")
+	}
+}
+
+func TestReader_CopyLimitTee(t *testing.T) {
+	var tee bytes.Buffer
+	wc := &testWriteCloser{}
+	n := WriteCloser{Ok[io.WriteCloser](wc)}.
+		Copy(StrOf("hello, world").Reader().Limit(5).TeeTo(&tee)).
+		Close().Must()
+	if n != 5 || wc.String() != "hello" || tee.String() != "hello" {
+		t.Fatalf("n=%d out=%q tee=%q", n, wc.String(), tee.String())
+	}
+}
+
+func TestReader_Lines(t *testing.T) {
+	out, errc := StrOf("a\nb\r\nc").Reader().Lines(context.Background())
+	got := Collect(nil, out).Must()
+	mustErr(t, <-errc)
+	if len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Fatalf("got %q", got)
+	}
+
+	rc := &testReadCloser{r: bytes.NewReader(nil)}
+	if err := (ReadCloser{Ok[io.ReadCloser](rc)}).Close(); err != nil || !rc.closed {
+		t.Fatalf("err=%v closed=%v", err, rc.closed)
+	}
+	if err := Open("/does/not/exist").Close(); err != nil {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestReadAllLimit(t *testing.T) {
+	if got := ReadAllLimit(bytes.NewReader([]byte("12345")), 5).Must(); string(got) != "12345" {
+		t.Fatalf("got %q", got)
+	}
+	_, err := ReadAllLimit(bytes.NewReader([]byte("123456789")), 5).Get()
+	var tl *ErrTooLarge
+	if !errors.As(err, &tl) || tl.Limit != 5 || tl.Size != 6 {
+		t.Fatalf("err = %#v", err)
+	}
+
+	rc := &testReadCloser{r: bytes.NewReader([]byte("123456789"))}
+	if _, err := SlurpLimit(rc, 3).Get(); !errors.As(err, &tl) || !rc.closed {
+		t.Fatalf("err=%v closed=%v", err, rc.closed)
+	}
+
+	path := filepath.Join(t.TempDir(), "big")
+	if err := os.WriteFile(path, bytes.Repeat([]byte("x"), 100), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path).SlurpLimit(99).Get(); !errors.As(err, &tl) {
+		t.Fatalf("err = %v", err)
+	}
+	if got := Open(path).SlurpLimit(100).Must(); len(got) != 100 {
+		t.Fatalf("got %d bytes", len(got))
+	}
+	if got := ReadAllLimit(bytes.NewReader([]byte("12345")), math.MaxInt64).Must(); string(got) != "12345" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestDefaultReadLimit(t *testing.T) {
+	defer func(old int64) { DefaultReadLimit = old }(DefaultReadLimit)
+	DefaultReadLimit = 4
+
+	var tl *ErrTooLarge
+	if _, err := StrOf("hello").Reader().ReadAll().Get(); !errors.As(err, &tl) || tl.Limit != 4 {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err := Slurp(io.NopCloser(bytes.NewReader([]byte("hello")))).Get(); !errors.As(err, &tl) {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err := BytesOf([]byte("hello")).Stream().Slurp().Get(); !errors.As(err, &tl) {
+		t.Fatalf("err = %v", err)
+	}
+	if got := StrOf("hello").Reader().ReadAllLimit(-1).Must(); string(got) != "hello" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestReader_MaxBytes(t *testing.T) {
+	r := StrOf("0123456789").Reader().MaxBytes(4)
+	buf := make([]byte, 3)
+	if n, err := r.v.Read(buf); n != 3 || err != nil {
+		t.Fatalf("n=%d err=%v", n, err)
+	}
+	// Unlike Limit, exceeding the limit is an error, even for streaming consumers.
+	var tl *ErrTooLarge
+	_, err := io.Copy(io.Discard, r.v)
+	if !errors.As(err, &tl) || tl.Limit != 4 || tl.Size != 5 {
+		t.Fatalf("err = %#v", err)
+	}
+	if got := StrOf("0123").Reader().MaxBytes(4).ReadAll().Must(); string(got) != "0123" {
+		t.Fatalf("got %q", got)
+	}
+	if got := StrOf("0123").Reader().MaxBytes(math.MaxInt64).ReadAll().Must(); string(got) != "0123" {
+		t.Fatalf("got %q", got)
+	}
+
+	rc := &testReadCloser{r: bytes.NewReader([]byte("0123456789"))}
+	if _, err := (ReadCloser{Ok[io.ReadCloser](rc)}).MaxBytes(8).Slurp().Get(); !errors.As(err, &tl) || !rc.closed {
+		t.Fatalf("err=%v closed=%v", err, rc.closed)
+	}
+}
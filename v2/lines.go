@@ -0,0 +1,188 @@
+package v2
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// LongLinePolicy decides what happens to lines longer than WithMaxLineLength.
+type LongLinePolicy int
+
+const (
+	// LongLineError fails with an error wrapping bufio.ErrTooLong (the default).
+	LongLineError LongLinePolicy = iota
+	// LongLineTruncate keeps the first max bytes and drops the rest of the line.
+	LongLineTruncate
+	// LongLineSplit emits the line in pieces of at most max bytes.
+	LongLineSplit
+)
+
+const defaultMaxLineLength = 1024 * 1024
+
+// WithMaxLineLength sets the longest line (without its line ending) ReadLines, Reader.Lines and
+// Str.Lines accept. The default is 1 MiB; n < 0 removes the limit.
+func WithMaxLineLength(n int) ChanOption {
+	return func(c *chanConfig) {
+		if c == nil {
+			return
+		}
+		c.maxLineLength = n
+		c.needs(featLines, "WithMaxLineLength")
+	}
+}
+
+// WithLongLines sets the policy for lines longer than WithMaxLineLength.
+func WithLongLines(p LongLinePolicy) ChanOption {
+	return func(c *chanConfig) {
+		if c == nil {
+			return
+		}
+		c.longLines = p
+		c.needs(featLines, "WithLongLines")
+	}
+}
+
+// Line is a line read by ReadLines. Text excludes the line ending ("\n" or "\r\n").
+type Line struct {
+	Text   string
+	Number int   // 1-based; the pieces of a split line share a number
+	Offset int64 // byte offset of Text in the input
+
+	Truncated bool // the rest of the line was dropped (LongLineTruncate)
+	Partial   bool // the line continues in the next piece (LongLineSplit)
+}
+
+// lineSplitter reads lines of any length from a reader, applying the long-line policy.
+type lineSplitter struct {
+	br     *bufio.Reader
+	max    int
+	policy LongLinePolicy
+
+	offset  int64  // bytes consumed from br
+	number  int    // number of the last line started
+	pending []byte // rest of a split line
+	eof     bool
+}
+
+func newLineSplitter(r io.Reader, cfg chanConfig) *lineSplitter {
+	max := cfg.maxLineLength
+	if max == 0 {
+		max = defaultMaxLineLength
+	}
+	return &lineSplitter{br: bufio.NewReader(r), max: max, policy: cfg.longLines}
+}
+
+// next returns the next line, or io.EOF once the input is exhausted.
+func (s *lineSplitter) next() (Line, error) {
+	if s.eof && len(s.pending) == 0 {
+		return Line{}, io.EOF
+	}
+	buf, continued := s.pending, s.pending != nil
+	start := s.offset - int64(len(buf))
+	s.pending = nil
+	if !continued {
+		s.number++
+	}
+	truncated := false
+	for {
+		complete := s.eof || bytes.HasSuffix(buf, []byte("\n"))
+		if !complete {
+			frag, err := s.br.ReadSlice('\n')
+			s.offset += int64(len(frag))
+			if !truncated {
+				buf = append(buf, frag...)
+			}
+			switch {
+			case err == nil:
+				complete = true
+			case errors.Is(err, io.EOF):
+				s.eof, complete = true, true
+				if len(buf) == 0 && !truncated {
+					return Line{}, io.EOF
+				}
+			case !errors.Is(err, bufio.ErrBufferFull):
+				return Line{}, err
+			}
+		}
+		if s.max > 0 && !truncated {
+			// An incomplete line may still lose one byte: the "\r" of a "\r\n".
+			tooLong := len(buf) > s.max+1
+			if complete {
+				tooLong = len(trimEOL(buf)) > s.max
+			}
+			if tooLong {
+				switch s.policy {
+				case LongLineTruncate:
+					truncated, buf = true, buf[:s.max]
+				case LongLineSplit:
+					s.pending = append([]byte(nil), buf[s.max:]...)
+					return Line{Text: string(buf[:s.max]), Number: s.number, Offset: start, Partial: true}, nil
+				default:
+					return Line{}, fmt.Errorf("lambda/v2: line %d: %w", s.number, bufio.ErrTooLong)
+				}
+			}
+		}
+		if complete {
+			if truncated {
+				return Line{Text: string(buf), Number: s.number, Offset: start, Truncated: true}, nil
+			}
+			return Line{Text: string(trimEOL(buf)), Number: s.number, Offset: start}, nil
+		}
+	}
+}
+
+func trimEOL(b []byte) []byte {
+	b = bytes.TrimSuffix(b, []byte("\n"))
+	return bytes.TrimSuffix(b, []byte("\r"))
+}
+
+// ReadLines emits the lines of r with their numbers and byte offsets. Lines may end in "\n" or
+// "\r\n"; a final line without a line ending is emitted too. Lines longer than
+// WithMaxLineLength are handled according to WithLongLines.
+func ReadLines(ctx context.Context, r io.Reader, opts ...ChanOption) (<-chan Line, <-chan error) {
+	return readLines(ctx, "ReadLines", r, opts, func(l Line) Line { return l })
+}
+
+func readLines[T any](ctx context.Context, name string, r io.Reader, opts []ChanOption, f func(Line) T) (<-chan T, <-chan error) {
+	if r == nil {
+		return closedErrStream[T](errNilReader)
+	}
+	cfg, err := chanCfg(opts, featLines)
+	if err != nil {
+		return closedErrStream[T](err)
+	}
+	out := make(chan T, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, name, errc)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		s := newLineSplitter(r, cfg)
+		for {
+			l, err := s.next()
+			if errors.Is(err, io.EOF) {
+				errc <- nil
+				return
+			}
+			if err != nil {
+				errc <- err
+				return
+			}
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case out <- f(l):
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
@@ -0,0 +1,108 @@
+package v2
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func readAllLines(t *testing.T, input string, opts ...ChanOption) ([]Line, error) {
+	t.Helper()
+	out, errc := ReadLines(context.Background(), strings.NewReader(input), opts...)
+	lines := Collect(nil, out).Must()
+	return lines, <-errc
+}
+
+func TestReadLines_NumbersOffsetsAndEndings(t *testing.T) {
+	t.Parallel()
+
+	lines, err := readAllLines(t, "first\r\n\nthird\nlast")
+	mustErr(t, err)
+	want := []Line{
+		{Text: "first", Number: 1, Offset: 0},
+		{Text: "", Number: 2, Offset: 7},
+		{Text: "third", Number: 3, Offset: 8},
+		{Text: "last", Number: 4, Offset: 14},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %+v", lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, lines[i], want[i])
+		}
+	}
+
+	if lines, _ := readAllLines(t, ""); len(lines) != 0 {
+		t.Fatalf("empty input gave %+v", lines)
+	}
+	if lines, _ := readAllLines(t, "only\n"); len(lines) != 1 || lines[0].Text != "only" {
+		t.Fatalf("got %+v", lines)
+	}
+}
+
+func TestReadLines_LongLinePolicies(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("x", 10_000)
+	input := "short\n" + long + "\r\nend"
+
+	_, err := readAllLines(t, input, WithMaxLineLength(4096))
+	if !errors.Is(err, bufio.ErrTooLong) || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("err = %v", err)
+	}
+
+	lines, err := readAllLines(t, input, WithMaxLineLength(4096), WithLongLines(LongLineTruncate))
+	mustErr(t, err)
+	if len(lines) != 3 || len(lines[1].Text) != 4096 || !lines[1].Truncated || lines[2].Text != "end" || lines[2].Offset != 10_008 {
+		t.Fatalf("truncate: %d lines, %+v", len(lines), lines[2])
+	}
+
+	lines, err = readAllLines(t, input, WithMaxLineLength(4096), WithLongLines(LongLineSplit))
+	mustErr(t, err)
+	var rebuilt strings.Builder
+	for _, l := range lines[1:4] {
+		if l.Number != 2 {
+			t.Fatalf("piece %+v", l)
+		}
+		rebuilt.WriteString(l.Text)
+	}
+	if rebuilt.String() != long || !lines[1].Partial || lines[3].Partial || lines[2].Offset != 6+4096 {
+		t.Fatalf("split: %d lines", len(lines))
+	}
+	if lines[4].Text != "end" || lines[4].Number != 3 {
+		t.Fatalf("last = %+v", lines[4])
+	}
+
+	// Minified JSON far beyond 1 MiB streams fine without a limit.
+	huge := strings.Repeat("y", 3<<20)
+	lines, err = readAllLines(t, huge, WithMaxLineLength(-1))
+	mustErr(t, err)
+	if len(lines) != 1 || len(lines[0].Text) != len(huge) {
+		t.Fatalf("got %d lines", len(lines))
+	}
+}
+
+func TestStrLines_Options(t *testing.T) {
+	t.Parallel()
+
+	got := StrOf("abcdef\r\ngh").Lines(WithMaxLineLength(4), WithLongLines(LongLineSplit)).Must()
+	if strings.Join(got, "|") != "abcd|ef|gh" {
+		t.Fatalf("got %q", got)
+	}
+	if _, err := StrOf(strings.Repeat("z", 2<<20)).Lines().Get(); !errors.Is(err, bufio.ErrTooLong) {
+		t.Fatalf("err = %v", err)
+	}
+	out, errc := StrOf("a\nbbbbb").Reader().Lines(context.Background(), WithMaxLineLength(2), WithLongLines(LongLineTruncate))
+	if got := Collect(nil, out).Must(); strings.Join(got, "|") != "a|bb" {
+		t.Fatalf("got %q", got)
+	}
+	mustErr(t, <-errc)
+
+	_, errc = FromSlice(context.Background(), []string{"a"}, WithMaxLineLength(2))
+	if err := <-errc; !errors.Is(err, errUnsupportedOption) {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -1,7 +1,6 @@
 package v2
 
 import (
-	"errors"
 	"io"
 
 	"github.com/charmbracelet/glamour"
@@ -42,7 +41,7 @@ func (m MarkdownRenderer) RenderReader(r io.Reader) Str {
 		return Str{Err[string](m.err)}
 	}
 	if r == nil {
-		return Str{Err[string](errors.New("lambda/v2: nil reader"))}
+		return Str{Err[string](errNilReader)}
 	}
 	b := ReadAll(r)
 	if b.err != nil {
@@ -0,0 +1,376 @@
+package v2
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// MetricsSink hands out the metrics of named stages. Stages sharing a name share their metrics.
+type MetricsSink interface {
+	Stage(name string) *StageMetrics
+}
+
+// StageMetrics collects the metrics of one pipeline stage. It is safe for concurrent use;
+// a nil *StageMetrics records nothing.
+type StageMetrics struct {
+	name string
+
+	in, out            atomic.Int64
+	recvWait, sendWait atomic.Int64 // ns
+	latSum, latCount   atomic.Int64
+	latMax             atomic.Int64
+	bufSum, bufSamples atomic.Int64
+	bufMax, bufCap     atomic.Int64
+
+	mu       sync.Mutex
+	inflight []time.Time
+}
+
+// StageSnapshot is a point-in-time copy of a stage's metrics.
+//
+// RecvWait is the time the stage waited for input, SendWait the time it was blocked by
+// downstream (backpressure). Buffer figures describe the stage's output buffer.
+type StageSnapshot struct {
+	Name       string
+	In, Out    int64
+	RecvWait   time.Duration
+	SendWait   time.Duration
+	AvgLatency time.Duration
+	MaxLatency time.Duration
+	AvgBuffer  float64
+	MaxBuffer  int64
+	BufferCap  int64
+}
+
+func newStageMetrics(name string) *StageMetrics { return &StageMetrics{name: name} }
+
+// Snapshot returns the current metrics.
+func (m *StageMetrics) Snapshot() StageSnapshot {
+	if m == nil {
+		return StageSnapshot{}
+	}
+	s := StageSnapshot{
+		Name:       m.name,
+		In:         m.in.Load(),
+		Out:        m.out.Load(),
+		RecvWait:   time.Duration(m.recvWait.Load()),
+		SendWait:   time.Duration(m.sendWait.Load()),
+		MaxLatency: time.Duration(m.latMax.Load()),
+		MaxBuffer:  m.bufMax.Load(),
+		BufferCap:  m.bufCap.Load(),
+	}
+	if n := m.latCount.Load(); n > 0 {
+		s.AvgLatency = time.Duration(m.latSum.Load() / n)
+	}
+	if n := m.bufSamples.Load(); n > 0 {
+		s.AvgBuffer = float64(m.bufSum.Load()) / float64(n)
+	}
+	return s
+}
+
+func storeMax(v *atomic.Int64, x int64) {
+	for {
+		cur := v.Load()
+		if x <= cur || v.CompareAndSwap(cur, x) {
+			return
+		}
+	}
+}
+
+func (m *StageMetrics) latency(d time.Duration) {
+	m.latSum.Add(int64(d))
+	m.latCount.Add(1)
+	storeMax(&m.latMax, int64(d))
+}
+
+// call records one call of a parallel helper's function that started at start.
+func (m *StageMetrics) call(start time.Time, ok bool) {
+	if m == nil {
+		return
+	}
+	m.in.Add(1)
+	m.latency(time.Since(start))
+	if ok {
+		m.out.Add(1)
+	}
+}
+
+// observe records the latency of a call that started at start.
+func (m *StageMetrics) observe(start time.Time) {
+	if m == nil {
+		return
+	}
+	m.latency(time.Since(start))
+}
+
+// received records an input value. With fifo, its arrival is kept to compute the latency
+// of the matching output (for order-preserving, one-to-one stages).
+func (m *StageMetrics) received(wait time.Duration, fifo bool) {
+	m.in.Add(1)
+	m.recvWait.Add(int64(wait))
+	if fifo {
+		m.mu.Lock()
+		m.inflight = append(m.inflight, time.Now())
+		m.mu.Unlock()
+	}
+}
+
+func (m *StageMetrics) sent(wait time.Duration, buffered, capacity int) {
+	m.out.Add(1)
+	m.sendWait.Add(int64(wait))
+	m.bufSum.Add(int64(buffered))
+	m.bufSamples.Add(1)
+	storeMax(&m.bufMax, int64(buffered))
+	m.bufCap.Store(int64(capacity))
+
+	m.mu.Lock()
+	var at time.Time
+	if len(m.inflight) > 0 {
+		at = m.inflight[0]
+		m.inflight = m.inflight[1:]
+	}
+	m.mu.Unlock()
+	if !at.IsZero() {
+		m.latency(time.Since(at))
+	}
+}
+
+// WithMetrics records the metrics of a channel helper under name in sink: values in and out,
+// time blocked on receive and send, output buffer occupancy and (for one-to-one stages) the
+// latency of every value.
+//
+// Helpers with several outputs record their input under name and every output as a stage of
+// its own, named after the output: name/1, name/2, ... for Tee, Broadcast and FanOut,
+// name/matched and name/unmatched for Partition, name/good and name/bad for SplitErrors.
+//
+// Metering proxies the stage's channels through a goroutine each, which changes backpressure:
+// every metered input and output holds one more value than WithBuffer says, and a stage that
+// stops reading early (Take, TakeWhile, ...) may consume one more input value.
+func WithMetrics(name string, sink MetricsSink) ChanOption {
+	return func(c *chanConfig) {
+		if c == nil {
+			return
+		}
+		c.meter = nil
+		if sink != nil {
+			c.meter = &meter{stats: sink.Stage(name), sink: sink, name: name}
+		}
+	}
+}
+
+// WithParMetrics is WithMetrics for the parallel helpers, which take ParOptions instead of
+// ChanOptions (Go has no overloading, so one function cannot return both). Latency is the
+// duration of every call of the mapped function.
+func WithParMetrics(name string, sink MetricsSink) ParOption {
+	metrics := WithMetrics(name, sink)
+	return func(c *parConfig) {
+		var cc chanConfig
+		metrics(&cc)
+		c.meter = cc.meter
+	}
+}
+
+// meter instruments a stage by proxying its channels.
+type meter struct {
+	stats *StageMetrics
+	fifo  bool
+
+	sink MetricsSink
+	name string
+
+	// Output proxies stop when the caller's context or the scope is done,
+	// not when the stage itself finishes (they still have values to deliver).
+	parent context.Context
+	scope  *Scope
+}
+
+// attachMeter binds the configured meter (if any) to ctx, like chanConfig.attach.
+func (c parConfig) attachMeter(ctx context.Context) *meter {
+	if c.meter == nil {
+		return nil
+	}
+	m := *c.meter
+	m.parent = ctx
+	return &m
+}
+
+// stats returns the metrics recorded for the parallel helper, nil if it isn't metered.
+func (c parConfig) stats() *StageMetrics {
+	if c.meter == nil {
+		return nil
+	}
+	return c.meter.stats
+}
+
+// output returns the meter of one output of a multi-output stage, recorded as name/suffix.
+func (m *meter) output(suffix string) *meter {
+	if m == nil {
+		return nil
+	}
+	return &meter{stats: m.sink.Stage(m.name + "/" + suffix), parent: m.parent, scope: m.scope}
+}
+
+// meterIn proxies in, recording values and receive waits. The proxy stops when ctx is done,
+// so ctx must be canceled once the stage stops reading.
+func meterIn[T any](ctx context.Context, m *meter, in <-chan T) <-chan T {
+	if m == nil || in == nil {
+		return in
+	}
+	ctx = ensureCtx(ctx)
+	proxy := make(chan T)
+	go func() {
+		defer close(proxy)
+		for {
+			start := time.Now()
+			var (
+				v  T
+				ok bool
+			)
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok = <-in:
+			}
+			if !ok {
+				return
+			}
+			m.stats.received(time.Since(start), m.fifo)
+			select {
+			case <-ctx.Done():
+				return
+			case proxy <- v:
+			}
+		}
+	}()
+	return proxy
+}
+
+// meterOut proxies out, recording values, send waits and buffer occupancy.
+func meterOut[T any](m *meter, out chan T) <-chan T {
+	if m == nil {
+		return out
+	}
+	parent := ensureCtx(m.parent)
+	var scopeDone <-chan struct{}
+	if m.scope != nil {
+		scopeDone = m.scope.ctx.Done()
+	}
+	proxy := make(chan T)
+	go func() {
+		defer close(proxy)
+		for v := range out {
+			buffered := len(out)
+			start := time.Now()
+			select {
+			case <-parent.Done():
+				return
+			case <-scopeDone:
+				return
+			case proxy <- v:
+			}
+			m.stats.sent(time.Since(start), buffered, cap(out))
+		}
+	}()
+	return proxy
+}
+
+func meterOuts[T any](m *meter, outs []chan T) []<-chan T {
+	ro := make([]<-chan T, len(outs))
+	for i, o := range outs {
+		ro[i] = meterOut(m.output(strconv.Itoa(i+1)), o)
+	}
+	return ro
+}
+
+// MemoryMetrics is an in-memory MetricsSink.
+type MemoryMetrics struct {
+	mu     sync.Mutex
+	stages map[string]*StageMetrics
+	order  []string
+}
+
+// NewMemoryMetrics creates an empty in-memory sink.
+func NewMemoryMetrics() *MemoryMetrics {
+	return &MemoryMetrics{stages: make(map[string]*StageMetrics)}
+}
+
+// Stage returns the metrics of name, creating them on first use.
+func (s *MemoryMetrics) Stage(name string) *StageMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.stages[name]
+	if !ok {
+		m = newStageMetrics(name)
+		s.stages[name] = m
+		s.order = append(s.order, name)
+	}
+	return m
+}
+
+// Snapshot returns the metrics of every stage in the order the stages were first seen.
+func (s *MemoryMetrics) Snapshot() []StageSnapshot {
+	s.mu.Lock()
+	stages := make([]*StageMetrics, len(s.order))
+	for i, name := range s.order {
+		stages[i] = s.stages[name]
+	}
+	s.mu.Unlock()
+
+	snaps := make([]StageSnapshot, len(stages))
+	for i, m := range stages {
+		snaps[i] = m.Snapshot()
+	}
+	return snaps
+}
+
+// ExpvarMetrics is a MetricsSink published via expvar (and thus /debug/vars).
+type ExpvarMetrics struct {
+	*MemoryMetrics
+}
+
+var (
+	expvarMu    sync.Mutex
+	expvarSinks = map[string]*ExpvarMetrics{}
+)
+
+// NewExpvarMetrics publishes a sink under the expvar name. The variable maps stage names to
+// their snapshots. Calling it again with the same name returns the same sink; like
+// expvar.Publish it panics if name is already taken by another variable.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	if s, ok := expvarSinks[name]; ok {
+		return s
+	}
+	s := &ExpvarMetrics{MemoryMetrics: NewMemoryMetrics()}
+	expvar.Publish(name, expvar.Func(func() any {
+		vars := make(map[string]StageSnapshot)
+		for _, snap := range s.Snapshot() {
+			vars[snap.Name] = snap
+		}
+		return vars
+	}))
+	expvarSinks[name] = s
+	return s
+}
+
+// WriteMetricsTable writes snaps as an aligned per-stage table.
+func WriteMetricsTable(w io.Writer, snaps []StageSnapshot) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STAGE\tIN\tOUT\tRECV WAIT\tSEND WAIT\tAVG LATENCY\tMAX LATENCY\tBUFFER AVG/MAX/CAP")
+	for _, s := range snaps {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%.1f/%d/%d\n",
+			s.Name, s.In, s.Out,
+			s.RecvWait.Round(time.Microsecond), s.SendWait.Round(time.Microsecond),
+			s.AvgLatency.Round(time.Microsecond), s.MaxLatency.Round(time.Microsecond),
+			s.AvgBuffer, s.MaxBuffer, s.BufferCap)
+	}
+	return tw.Flush()
+}
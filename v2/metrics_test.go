@@ -0,0 +1,171 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"expvar"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMetrics_Pipeline(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sink := NewMemoryMetrics()
+
+	n := 0
+	gen, e1 := Generate(ctx, func(context.Context) (int, bool, error) {
+		n++
+		return n, n <= 20, nil
+	}, WithMetrics("generate", sink))
+	sq, e2 := ParMapChan(ctx, gen, func(v int) int {
+		time.Sleep(time.Millisecond)
+		return v * v
+	}, WithConcurrency(4), WithParMetrics("square", sink))
+	a, b, e3 := Tee(ctx, sq, WithMetrics("tee", sink), WithBuffer(2))
+
+	var (
+		wg     sync.WaitGroup
+		ga, gb []int
+	)
+	wg.Add(2)
+	go func() { defer wg.Done(); ga = Collect(ctx, a, WithMetrics("collect a", sink)).Must() }()
+	go func() { defer wg.Done(); gb = Collect(ctx, b).Must() }()
+	wg.Wait()
+	mustErr(t, JoinErr(e1, e2, e3))
+	if len(ga) != 20 || len(gb) != 20 {
+		t.Fatalf("got %d and %d values", len(ga), len(gb))
+	}
+
+	snaps := sink.Snapshot()
+	byName := map[string]StageSnapshot{}
+	var order []string
+	for _, s := range snaps {
+		byName[s.Name] = s
+		order = append(order, s.Name)
+	}
+	if strings.Join(order, ",") != "generate,square,tee,tee/1,tee/2,collect a" {
+		t.Fatalf("order = %v", order)
+	}
+	if s := byName["generate"]; s.In != 0 || s.Out != 20 {
+		t.Fatalf("generate: %+v", s)
+	}
+	if s := byName["square"]; s.In != 20 || s.Out != 20 || s.AvgLatency < time.Millisecond || s.MaxLatency < s.AvgLatency {
+		t.Fatalf("square: %+v", s)
+	}
+	if s := byName["tee"]; s.In != 20 || s.Out != 0 {
+		t.Fatalf("tee: %+v", s)
+	}
+	for _, name := range []string{"tee/1", "tee/2"} {
+		if s := byName[name]; s.In != 0 || s.Out != 20 || s.BufferCap != 2 {
+			t.Fatalf("%s: %+v", name, s)
+		}
+	}
+	if s := byName["collect a"]; s.In != 20 || s.Out != 0 {
+		t.Fatalf("collect: %+v", s)
+	}
+}
+
+func TestMetrics_Backpressure(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sink := NewMemoryMetrics()
+	nums, e1 := RangeN(ctx, 20, WithMetrics("source", sink))
+	sq, e2 := MapChan(ctx, nums, func(v int) int { return v * v }, WithMetrics("map", sink))
+
+	for range sq {
+		time.Sleep(2 * time.Millisecond)
+	}
+	mustErr(t, JoinErr(e1, e2))
+
+	snaps := sink.Snapshot()
+	src, m := snaps[0], snaps[1]
+	if src.SendWait < 10*time.Millisecond {
+		t.Fatalf("source send wait = %v", src.SendWait)
+	}
+	if m.In != 20 || m.Out != 20 || m.SendWait < 10*time.Millisecond {
+		t.Fatalf("map: %+v", m)
+	}
+	if m.AvgLatency <= 0 {
+		t.Fatalf("map latency = %v", m.AvgLatency)
+	}
+}
+
+func TestMetrics_NoLeakOnEarlyStop(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sink := NewMemoryMetrics()
+	ones, e1 := Repeat(ctx, 1, WithMetrics("repeat", sink))
+	first, e2 := Take(ctx, ones, 3, WithMetrics("take", sink))
+	if got := Collect(ctx, first).Must(); len(got) != 3 {
+		t.Fatalf("got %v", got)
+	}
+	mustErr(t, <-e2)
+	cancel()
+	<-e1
+
+	// The input proxy may have read one value ahead.
+	if s := sink.Stage("take").Snapshot(); s.In < 3 || s.In > 4 || s.Out != 3 {
+		t.Fatalf("take: %+v", s)
+	}
+	waitGoroutines(t, before)
+}
+
+func TestParMetrics_Slice(t *testing.T) {
+	t.Parallel()
+
+	sink := NewMemoryMetrics()
+	got := ParTry(context.Background(), []int{1, 2, 3}, func(v int) (int, error) {
+		return v, nil
+	}, WithParMetrics("try", sink)).Must()
+	if len(got) != 3 {
+		t.Fatalf("got %v", got)
+	}
+	if s := sink.Stage("try").Snapshot(); s.In != 3 || s.Out != 3 || s.MaxLatency <= 0 {
+		t.Fatalf("try: %+v", s)
+	}
+}
+
+func TestWriteMetricsTable(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	err := WriteMetricsTable(&buf, []StageSnapshot{
+		{Name: "source", Out: 10, SendWait: 3 * time.Millisecond},
+		{Name: "sink", In: 10, BufferCap: 4, MaxBuffer: 2, AvgBuffer: 1.5},
+	})
+	mustErr(t, err)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "STAGE") {
+		t.Fatalf("table:\n%s", buf.String())
+	}
+	if !strings.HasPrefix(lines[1], "source") || !strings.Contains(lines[1], "3ms") {
+		t.Fatalf("row: %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "1.5/2/4") {
+		t.Fatalf("row: %q", lines[2])
+	}
+}
+
+func TestExpvarMetrics(t *testing.T) {
+	t.Parallel()
+
+	sink := NewExpvarMetrics("lambda_v2_test_metrics")
+	if NewExpvarMetrics("lambda_v2_test_metrics") != sink {
+		t.Fatal("expected the same sink for the same name")
+	}
+	nums, errc := RangeN(context.Background(), 3, WithMetrics("expvar source", sink))
+	Drain(context.Background(), nums)
+	mustErr(t, <-errc)
+
+	v := expvar.Get("lambda_v2_test_metrics")
+	if v == nil || !strings.Contains(v.String(), `"expvar source"`) {
+		t.Fatalf("expvar = %v", v)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"runtime"
 	"sync"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 )
@@ -17,6 +18,8 @@ type TryFn[T, U any] func(T) (U, error)
 
 type parConfig struct {
 	concurrency int
+	limiter     *Limiter
+	meter       *meter
 }
 
 // ParOption configures parallel helpers like ParMap/ParTry.
@@ -82,7 +85,15 @@ func ParMap[T, U any](ctx context.Context, in []T, f MapFn[T, U], opts ...ParOpt
 			default:
 			}
 
+			if cfg.limiter != nil {
+				if err := cfg.limiter.Wait(gctx); err != nil {
+					return err
+				}
+			}
+
+			start := time.Now()
 			v := f(in[i])
+			cfg.stats().call(start, true)
 
 			select {
 			case <-gctx.Done():
@@ -128,7 +139,15 @@ func ParTry[T, U any](ctx context.Context, in []T, f TryFn[T, U], opts ...ParOpt
 			default:
 			}
 
+			if cfg.limiter != nil {
+				if err := cfg.limiter.Wait(gctx); err != nil {
+					return err
+				}
+			}
+
+			start := time.Now()
 			v, err := f(in[i])
+			cfg.stats().call(start, err == nil)
 			if err != nil {
 				return err
 			}
@@ -180,7 +199,15 @@ func ParMapUnordered[T, U any](ctx context.Context, in []T, f MapFn[T, U], opts
 			default:
 			}
 
+			if cfg.limiter != nil {
+				if err := cfg.limiter.Wait(gctx); err != nil {
+					return err
+				}
+			}
+
+			start := time.Now()
 			v := f(in[i])
+			cfg.stats().call(start, true)
 
 			select {
 			case <-gctx.Done():
@@ -233,7 +260,15 @@ func ParTryUnordered[T, U any](ctx context.Context, in []T, f TryFn[T, U], opts
 			default:
 			}
 
+			if cfg.limiter != nil {
+				if err := cfg.limiter.Wait(gctx); err != nil {
+					return err
+				}
+			}
+
+			start := time.Now()
 			v, err := f(in[i])
+			cfg.stats().call(start, err == nil)
 			if err != nil {
 				return err
 			}
@@ -3,19 +3,26 @@ package v2
 import (
 	"context"
 	"errors"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 )
 
-var errNilChan = errors.New("lambda/v2: nil input channel")
+var (
+	errNilChan   = errors.New("lambda/v2: nil input channel")
+	errNilWriter = errors.New("lambda/v2: nil writer")
+	errNilReader = errors.New("lambda/v2: nil reader")
+)
 
 // ParMapChan maps values read from in in parallel and sends results to the returned channel.
 // Result order is not guaranteed.
 //
 // The returned err channel yields exactly one error (possibly nil) and then closes.
 func ParMapChan[T, U any](ctx context.Context, in <-chan T, f MapFn[T, U], opts ...ParOption) (<-chan U, <-chan error) {
+	cfg, cfgErr := parCfg(opts)
 	out := make(chan U)
 	errc := make(chan error, 1)
+	m := cfg.attachMeter(ctx)
 
 	go func() {
 		defer close(out)
@@ -29,13 +36,17 @@ func ParMapChan[T, U any](ctx context.Context, in <-chan T, f MapFn[T, U], opts
 			errc <- ErrNilFunc("ParMapChan")
 			return
 		}
-		cfg, err := parCfg(opts)
-		if err != nil {
-			errc <- err
+		if cfgErr != nil {
+			errc <- cfgErr
 			return
 		}
 
 		ctx = ensureCtx(ctx)
+		if m != nil {
+			mctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			in = meterIn(mctx, m, in)
+		}
 		g, gctx := errgroup.WithContext(ctx)
 		g.SetLimit(cfg.concurrency)
 
@@ -44,7 +55,7 @@ func ParMapChan[T, U any](ctx context.Context, in <-chan T, f MapFn[T, U], opts
 			case <-gctx.Done():
 				// Stop scheduling new work; wait for in-flight workers to exit.
 				cerr := gctx.Err()
-				err = g.Wait()
+				err := g.Wait()
 				if err == nil && cerr != nil {
 					err = cerr
 				}
@@ -65,7 +76,15 @@ func ParMapChan[T, U any](ctx context.Context, in <-chan T, f MapFn[T, U], opts
 					default:
 					}
 
+					if cfg.limiter != nil {
+						if err := cfg.limiter.Wait(gctx); err != nil {
+							return err
+						}
+					}
+
+					start := time.Now()
 					u := f(vv)
+					cfg.stats().observe(start)
 
 					select {
 					case <-gctx.Done():
@@ -78,7 +97,7 @@ func ParMapChan[T, U any](ctx context.Context, in <-chan T, f MapFn[T, U], opts
 		}
 	}()
 
-	return out, errc
+	return meterOut(m, out), errc
 }
 
 // ParTryChan maps values read from in in parallel and sends results to the returned channel.
@@ -87,8 +106,10 @@ func ParMapChan[T, U any](ctx context.Context, in <-chan T, f MapFn[T, U], opts
 // If any invocation returns an error, the first error is returned (fail-fast) and work is canceled.
 // The returned err channel yields exactly one error (possibly nil) and then closes.
 func ParTryChan[T, U any](ctx context.Context, in <-chan T, f TryFn[T, U], opts ...ParOption) (<-chan U, <-chan error) {
+	cfg, cfgErr := parCfg(opts)
 	out := make(chan U)
 	errc := make(chan error, 1)
+	m := cfg.attachMeter(ctx)
 
 	go func() {
 		defer close(out)
@@ -102,13 +123,17 @@ func ParTryChan[T, U any](ctx context.Context, in <-chan T, f TryFn[T, U], opts
 			errc <- ErrNilFunc("ParTryChan")
 			return
 		}
-		cfg, err := parCfg(opts)
-		if err != nil {
-			errc <- err
+		if cfgErr != nil {
+			errc <- cfgErr
 			return
 		}
 
 		ctx = ensureCtx(ctx)
+		if m != nil {
+			mctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			in = meterIn(mctx, m, in)
+		}
 		g, gctx := errgroup.WithContext(ctx)
 		g.SetLimit(cfg.concurrency)
 
@@ -117,7 +142,7 @@ func ParTryChan[T, U any](ctx context.Context, in <-chan T, f TryFn[T, U], opts
 			case <-gctx.Done():
 				// Stop scheduling new work; wait for in-flight workers to exit.
 				cerr := gctx.Err()
-				err = g.Wait()
+				err := g.Wait()
 				if err == nil && cerr != nil {
 					err = cerr
 				}
@@ -138,7 +163,15 @@ func ParTryChan[T, U any](ctx context.Context, in <-chan T, f TryFn[T, U], opts
 					default:
 					}
 
+					if cfg.limiter != nil {
+						if err := cfg.limiter.Wait(gctx); err != nil {
+							return err
+						}
+					}
+
+					start := time.Now()
 					u, err := f(vv)
+					cfg.stats().observe(start)
 					if err != nil {
 						return err
 					}
@@ -154,5 +187,5 @@ func ParTryChan[T, U any](ctx context.Context, in <-chan T, f TryFn[T, U], opts
 		}
 	}()
 
-	return out, errc
+	return meterOut(m, out), errc
 }
@@ -0,0 +1,371 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	errInvalidRate     = errors.New("lambda/v2: rate must be > 0")
+	errInvalidInterval = errors.New("lambda/v2: interval must be > 0")
+)
+
+// Limiter is a token-bucket rate limiter. It is safe for concurrent use.
+//
+// Tokens are added at rate per second up to burst; every Wait takes one token.
+type Limiter struct {
+	mu     sync.Mutex
+	clock  Clock
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter creates a limiter allowing rate events per second with bursts of up to burst events.
+// The bucket starts full. burst values below 1 are treated as 1.
+func NewLimiter(rate float64, burst int) *Limiter {
+	return newLimiter(rate, burst, realClock{})
+}
+
+func newLimiter(rate float64, burst int, clock Clock) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &Limiter{clock: clock, rate: rate, burst: float64(burst), tokens: float64(burst), last: clock.Now()}
+}
+
+// WithLimiter makes parallel helpers (ParMap, ParTry, ParMapChan, ...) wait for l before every call.
+// The same limiter can be shared by several pipelines.
+func WithLimiter(l *Limiter) ParOption {
+	return func(c *parConfig) {
+		if c == nil {
+			return
+		}
+		c.limiter = l
+	}
+}
+
+// reserve takes a token if one is available, otherwise it returns how long to wait for one.
+func (l *Limiter) reserve() (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = math.Min(l.burst, l.tokens+elapsed.Seconds()*l.rate)
+	}
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0, true
+	}
+	need := (1 - l.tokens) / l.rate
+	return time.Duration(math.Ceil(need * float64(time.Second))), false
+}
+
+// Allow takes a token if one is available and reports whether it did.
+func (l *Limiter) Allow() bool {
+	if l == nil || l.rate <= 0 {
+		return false
+	}
+	_, ok := l.reserve()
+	return ok
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	if l.rate <= 0 {
+		return errInvalidRate
+	}
+	ctx = ensureCtx(ctx)
+	for {
+		d, ok := l.reserve()
+		if ok {
+			return nil
+		}
+		t := l.clock.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C():
+		}
+	}
+}
+
+// RateLimit forwards values from in at no more than rate values per second, with bursts of up to burst.
+func RateLimit[T any](ctx context.Context, in <-chan T, rate float64, burst int, opts ...ChanOption) (<-chan T, <-chan error) {
+	if in == nil {
+		return closedErrStream[T](errNilChan)
+	}
+	if rate <= 0 {
+		return closedErrStream[T](errInvalidRate)
+	}
+	cfg, err := chanCfg(opts, featClock)
+	if err != nil {
+		return closedErrStream[T](err)
+	}
+	out := make(chan T, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "RateLimit", errc)
+	in = meterIn(ctx, cfg.meter, in)
+	lim := newLimiter(rate, burst, cfg.clock)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case v, ok := <-in:
+				if !ok {
+					errc <- nil
+					return
+				}
+				if err := lim.Wait(ctx); err != nil {
+					errc <- err
+					return
+				}
+				select {
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				case out <- v:
+				}
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+// Throttle emits the latest value received from in once per interval (sampling).
+// Values superseded within an interval are dropped. A pending value is flushed when in closes.
+func Throttle[T any](ctx context.Context, in <-chan T, interval time.Duration, opts ...ChanOption) (<-chan T, <-chan error) {
+	if in == nil {
+		return closedErrStream[T](errNilChan)
+	}
+	if interval <= 0 {
+		return closedErrStream[T](errInvalidInterval)
+	}
+	cfg, err := chanCfg(opts, featClock)
+	if err != nil {
+		return closedErrStream[T](err)
+	}
+	out := make(chan T, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Throttle", errc)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		var (
+			latest  T
+			pending bool
+		)
+		timer := cfg.clock.NewTimer(interval)
+		defer func() { timer.Stop() }()
+
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case <-timer.C():
+				timer = cfg.clock.NewTimer(interval)
+				if !pending {
+					continue
+				}
+				pending = false
+				select {
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				case out <- latest:
+				}
+			case v, ok := <-in:
+				if !ok {
+					if pending {
+						select {
+						case <-ctx.Done():
+							errc <- ctx.Err()
+							return
+						case out <- latest:
+						}
+					}
+					errc <- nil
+					return
+				}
+				latest, pending = v, true
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+// Debounce emits a value from in only after d has passed without a newer value.
+// A pending value is flushed when in closes.
+func Debounce[T any](ctx context.Context, in <-chan T, d time.Duration, opts ...ChanOption) (<-chan T, <-chan error) {
+	if in == nil {
+		return closedErrStream[T](errNilChan)
+	}
+	if d <= 0 {
+		return closedErrStream[T](errInvalidInterval)
+	}
+	cfg, err := chanCfg(opts, featClock)
+	if err != nil {
+		return closedErrStream[T](err)
+	}
+	out := make(chan T, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Debounce", errc)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		var (
+			latest  T
+			pending bool
+			timer   Timer
+			timerC  <-chan time.Time
+		)
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case <-timerC:
+				timer, timerC = nil, nil
+				pending = false
+				select {
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				case out <- latest:
+				}
+			case v, ok := <-in:
+				if !ok {
+					if pending {
+						select {
+						case <-ctx.Done():
+							errc <- ctx.Err()
+							return
+						case out <- latest:
+						}
+					}
+					errc <- nil
+					return
+				}
+				latest, pending = v, true
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = cfg.clock.NewTimer(d)
+				timerC = timer.C()
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+// Delay forwards every value from in d after it was received. Order is preserved.
+func Delay[T any](ctx context.Context, in <-chan T, d time.Duration, opts ...ChanOption) (<-chan T, <-chan error) {
+	if in == nil {
+		return closedErrStream[T](errNilChan)
+	}
+	if d < 0 {
+		return closedErrStream[T](errInvalidInterval)
+	}
+	cfg, err := chanCfg(opts, featClock)
+	if err != nil {
+		return closedErrStream[T](err)
+	}
+	out := make(chan T, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Delay", errc)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		var (
+			queue  []stamped[T]
+			timer  Timer
+			timerC <-chan time.Time
+			src    = in
+		)
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+		arm := func() {
+			if timer != nil || len(queue) == 0 {
+				return
+			}
+			timer = cfg.clock.NewTimer(queue[0].at.Sub(cfg.clock.Now()))
+			timerC = timer.C()
+		}
+
+		for src != nil || len(queue) > 0 {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case <-timerC:
+				timer, timerC = nil, nil
+				now := cfg.clock.Now()
+				for len(queue) > 0 && !queue[0].at.After(now) {
+					select {
+					case <-ctx.Done():
+						errc <- ctx.Err()
+						return
+					case out <- queue[0].v:
+					}
+					queue = queue[1:]
+				}
+				arm()
+			case v, ok := <-src:
+				if !ok {
+					src = nil
+					continue
+				}
+				queue = append(queue, stamped[T]{at: cfg.clock.Now().Add(d), v: v})
+				arm()
+			}
+		}
+		errc <- nil
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
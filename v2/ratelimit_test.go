@@ -0,0 +1,171 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	l := newLimiter(1, 2, clock)
+	if !l.Allow() || !l.Allow() {
+		t.Fatalf("burst should allow two events")
+	}
+	if l.Allow() {
+		t.Fatalf("third event should be limited")
+	}
+	clock.Advance(time.Second)
+	if !l.Allow() {
+		t.Fatalf("token should be refilled after 1s")
+	}
+}
+
+func TestLimiter_WaitCanceled(t *testing.T) {
+	t.Parallel()
+
+	l := newLimiter(1, 1, newTestClock())
+	mustErr(t, l.Wait(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err=%v, want context.Canceled", err)
+	}
+}
+
+func TestWithLimiter_ParMap(t *testing.T) {
+	t.Parallel()
+
+	// The clock never advances, so only the burst gets through.
+	l := newLimiter(1, 3, newTestClock())
+	var calls int64
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := ParMap(ctx, []int{1, 2, 3, 4, 5}, MapFn[int, int](func(v int) int {
+		atomic.AddInt64(&calls, 1)
+		return v
+	}), WithLimiter(l), WithConcurrency(5)).Err()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err=%v, want context.DeadlineExceeded", err)
+	}
+	if got := atomic.LoadInt64(&calls); got != 3 {
+		t.Fatalf("calls=%d, want 3", got)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	src, srcErrc := FromSlice(context.Background(), []int{1, 2, 3})
+	out, errc := RateLimit(context.Background(), src, 1, 1, WithClock(clock))
+
+	if v := <-out; v != 1 {
+		t.Fatalf("got %d, want 1", v)
+	}
+	waitTimers(t, clock, 1)
+	clock.Advance(time.Second)
+	if v := <-out; v != 2 {
+		t.Fatalf("got %d, want 2", v)
+	}
+	waitTimers(t, clock, 2)
+	clock.Advance(time.Second)
+	if v := <-out; v != 3 {
+		t.Fatalf("got %d, want 3", v)
+	}
+	for range out {
+	}
+	mustErr(t, JoinErr(srcErrc, errc))
+}
+
+func TestThrottle(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	in := make(chan int)
+	out, errc := Throttle(context.Background(), in, time.Second, WithClock(clock))
+
+	waitTimers(t, clock, 1)
+	in <- 1
+	in <- 2
+	in <- 3
+	clock.Advance(time.Second)
+	if v := <-out; v != 3 {
+		t.Fatalf("got %d, want 3", v)
+	}
+
+	in <- 4
+	close(in)
+	if v := <-out; v != 4 {
+		t.Fatalf("got %d, want 4", v)
+	}
+	mustErr(t, <-errc)
+}
+
+func TestDebounce(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	in := make(chan string)
+	out, errc := Debounce(context.Background(), in, time.Second, WithClock(clock))
+
+	in <- "a"
+	waitTimers(t, clock, 1)
+	clock.Advance(500 * time.Millisecond)
+	in <- "b"
+	waitTimers(t, clock, 2)
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case v := <-out:
+		t.Fatalf("unexpected early value %q", v)
+	default:
+	}
+	clock.Advance(500 * time.Millisecond)
+	if v := <-out; v != "b" {
+		t.Fatalf("got %q, want b", v)
+	}
+
+	close(in)
+	for range out {
+	}
+	mustErr(t, <-errc)
+}
+
+func TestDelay(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	in := make(chan int)
+	out, errc := Delay(context.Background(), in, time.Second, WithClock(clock))
+
+	in <- 1
+	waitTimers(t, clock, 1)
+	clock.Advance(time.Second)
+	if v := <-out; v != 1 {
+		t.Fatalf("got %d, want 1", v)
+	}
+
+	in <- 2
+	close(in)
+	waitTimers(t, clock, 2)
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case v := <-out:
+		t.Fatalf("unexpected early value %d", v)
+	default:
+	}
+	clock.Advance(500 * time.Millisecond)
+	if v := <-out; v != 2 {
+		t.Fatalf("got %d, want 2", v)
+	}
+	if _, ok := <-out; ok {
+		t.Fatalf("expected closed output")
+	}
+	mustErr(t, <-errc)
+}
@@ -0,0 +1,227 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errStageDone = errors.New("lambda/v2: stage done")
+
+// ScopeTimeoutError is returned by Scope.Wait when stages are still running after the wait timeout.
+type ScopeTimeoutError struct {
+	Timeout time.Duration
+	Running []string
+}
+
+func (e *ScopeTimeoutError) Error() string {
+	return fmt.Sprintf("lambda/v2: scope wait timed out after %s; still running: %s", e.Timeout, strings.Join(e.Running, ", "))
+}
+
+type scopeConfig struct {
+	timeout time.Duration
+	debug   io.Writer
+}
+
+// ScopeOption configures a Scope.
+type ScopeOption func(*scopeConfig)
+
+// WithWaitTimeout makes Scope.Wait give up after d and return a *ScopeTimeoutError.
+func WithWaitTimeout(d time.Duration) ScopeOption {
+	return func(c *scopeConfig) {
+		if c == nil {
+			return
+		}
+		c.timeout = d
+	}
+}
+
+// WithDebug makes Scope.Wait dump the names of still-running stages to w when it times out.
+func WithDebug(w io.Writer) ScopeOption {
+	return func(c *scopeConfig) {
+		if c == nil {
+			return
+		}
+		c.debug = w
+	}
+}
+
+// Scope tracks the goroutines of a pipeline (structured concurrency).
+//
+// Stages join a scope via the WithScope ChanOption, Track or Go. The first stage error
+// cancels the scope's context, which stops every attached stage; Wait blocks until all
+// of them have returned and reports the joined errors.
+type Scope struct {
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	cfg    scopeConfig
+
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	nextID  int
+	running map[int]string
+	errs    []error
+}
+
+// NewScope creates a Scope whose context is derived from ctx.
+func NewScope(ctx context.Context, opts ...ScopeOption) *Scope {
+	ctx = ensureCtx(ctx)
+	sctx, cancel := context.WithCancelCause(ctx)
+	s := &Scope{parent: ctx, ctx: sctx, cancel: cancel, running: make(map[int]string)}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&s.cfg)
+		}
+	}
+	return s
+}
+
+// WithScope attaches channel helpers to s: their goroutines are tracked by s and stopped
+// when s is canceled.
+func WithScope(s *Scope) ChanOption {
+	return func(c *chanConfig) {
+		if c == nil {
+			return
+		}
+		c.scope = s
+	}
+}
+
+// Context returns the scope's context. It is canceled on the first error or by Cancel.
+func (s *Scope) Context() context.Context { return s.ctx }
+
+// Cancel stops every stage attached to the scope.
+func (s *Scope) Cancel() { s.cancel(context.Canceled) }
+
+func (s *Scope) enter(name string) int {
+	s.wg.Add(1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.nextID
+	s.nextID++
+	s.running[id] = name
+	return id
+}
+
+func (s *Scope) exit(id int, name string, err error) {
+	if err != nil && !(isCtxErr(err) && s.ctx.Err() != nil) {
+		s.mu.Lock()
+		s.errs = append(s.errs, fmt.Errorf("%s: %w", name, err))
+		s.mu.Unlock()
+		s.cancel(err)
+	}
+	s.mu.Lock()
+	delete(s.running, id)
+	s.mu.Unlock()
+	s.wg.Done()
+}
+
+// Go runs fn in a tracked goroutine with the scope's context.
+func (s *Scope) Go(name string, fn func(context.Context) error) {
+	id := s.enter(name)
+	go func() {
+		var err error
+		if fn == nil {
+			err = ErrNilFunc("Scope.Go")
+		} else {
+			err = fn(s.ctx)
+		}
+		s.exit(id, name, err)
+	}()
+}
+
+// Track registers a stage by its error channel. The stage counts as running until it reports
+// its error, which is forwarded on the returned channel.
+func (s *Scope) Track(name string, errc <-chan error) <-chan error {
+	out, _ := s.track(name, errc, nil)
+	return out
+}
+
+func (s *Scope) track(name string, errc <-chan error, done func()) (<-chan error, int) {
+	id := s.enter(name)
+	out := make(chan error, 1)
+	go func() {
+		defer close(out)
+		var err error
+		if errc == nil {
+			err = errNilErrChan
+		} else {
+			err = <-errc
+		}
+		if done != nil {
+			done()
+		}
+		s.exit(id, name, err)
+		out <- err
+	}()
+	return out, id
+}
+
+// attach binds a stage's context to the scope and tracks its error channel.
+func (s *Scope) attach(ctx context.Context, name string, errc <-chan error) (context.Context, <-chan error) {
+	sctx, cancel := context.WithCancelCause(ensureCtx(ctx))
+	stop := context.AfterFunc(s.ctx, func() { cancel(context.Cause(s.ctx)) })
+	tracked, _ := s.track(name, errc, func() {
+		stop()
+		cancel(errStageDone)
+	})
+	return sctx, tracked
+}
+
+// Running returns the names of stages that have not finished yet, sorted.
+func (s *Scope) Running() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.running))
+	for _, name := range s.running {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Wait blocks until every tracked goroutine has returned and reports their joined errors.
+// Errors caused by the scope's own cancellation are left out. Once every goroutine has
+// returned, the scope's context is canceled; the scope must not be reused afterwards.
+func (s *Scope) Wait() error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	if s.cfg.timeout > 0 {
+		t := time.NewTimer(s.cfg.timeout)
+		defer t.Stop()
+		select {
+		case <-done:
+		case <-t.C:
+			err := &ScopeTimeoutError{Timeout: s.cfg.timeout, Running: s.Running()}
+			if s.cfg.debug != nil {
+				fmt.Fprintf(s.cfg.debug, "lambda/v2: scope still running after %s:\n", s.cfg.timeout)
+				for _, name := range err.Running {
+					fmt.Fprintf(s.cfg.debug, "\t%s\n", name)
+				}
+			}
+			return err
+		}
+	} else {
+		<-done
+	}
+	// Every stage has returned: release the context so it detaches from the parent.
+	s.cancel(nil)
+
+	s.mu.Lock()
+	errs := append([]error(nil), s.errs...)
+	s.mu.Unlock()
+	if len(errs) == 0 && s.parent.Err() != nil {
+		return s.parent.Err()
+	}
+	return errors.Join(errs...)
+}
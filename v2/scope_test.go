@@ -0,0 +1,149 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScope_Pipeline(t *testing.T) {
+	t.Parallel()
+
+	sc := NewScope(context.Background())
+	nums, e1 := Range(nil, 0, 5, WithScope(sc))
+	sq, e2 := MapChan(nil, nums, func(x int) int { return x * x }, WithScope(sc))
+
+	got := Collect(nil, sq).Must()
+	if !intsEqual(got, []int{0, 1, 4, 9, 16}) {
+		t.Fatalf("got %v", got)
+	}
+	if err := JoinErr(e1, e2); err != nil {
+		t.Fatalf("stage err: %v", err)
+	}
+	if err := sc.Wait(); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if r := sc.Running(); len(r) != 0 {
+		t.Fatalf("running: %v", r)
+	}
+	// A successful Wait releases the scope's context.
+	if err := sc.Context().Err(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("scope ctx err = %v", err)
+	}
+}
+
+func TestScope_FirstErrorCancelsAll(t *testing.T) {
+	before := runtime.NumGoroutine()
+	boom := errors.New("boom")
+
+	sc := NewScope(context.Background())
+	ones, e1 := Repeat(nil, 1, WithScope(sc))
+	kept, e2 := FilterChan(nil, ones, func(int) bool { return false }, WithScope(sc))
+	sc.Go("fail", func(ctx context.Context) error { return boom })
+
+	err := sc.Wait()
+	if !errors.Is(err, boom) {
+		t.Fatalf("Wait: %v", err)
+	}
+	if !strings.Contains(err.Error(), "fail") || strings.Contains(err.Error(), "Repeat") {
+		t.Fatalf("Wait: %v", err)
+	}
+	if !errors.Is(context.Cause(sc.Context()), boom) {
+		t.Fatalf("cause: %v", context.Cause(sc.Context()))
+	}
+	Drain(nil, kept)
+	if err := <-e1; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Repeat err: %v", err)
+	}
+	// FilterChan sees either the cancellation or its closed input first.
+	if err := <-e2; err != nil && !errors.Is(err, context.Canceled) {
+		t.Fatalf("FilterChan err: %v", err)
+	}
+	waitGoroutines(t, before)
+}
+
+func TestScope_Track(t *testing.T) {
+	t.Parallel()
+
+	boom := errors.New("boom")
+	sc := NewScope(context.Background())
+	nums, e1 := RangeN(sc.Context(), 3)
+	out, e2 := ParTryChan(sc.Context(), nums, func(x int) (int, error) {
+		if x == 1 {
+			return 0, boom
+		}
+		return x, nil
+	})
+	e1 = sc.Track("RangeN", e1)
+	e2 = sc.Track("ParTryChan", e2)
+	Drain(nil, out)
+
+	if err := sc.Wait(); !errors.Is(err, boom) || !strings.Contains(err.Error(), "ParTryChan") {
+		t.Fatalf("Wait: %v", err)
+	}
+	if err := <-e2; !errors.Is(err, boom) {
+		t.Fatalf("forwarded err: %v", err)
+	}
+	<-e1
+}
+
+func TestScope_WaitTimeoutDebug(t *testing.T) {
+	before := runtime.NumGoroutine()
+	var buf bytes.Buffer
+
+	sc := NewScope(context.Background(), WithWaitTimeout(20*time.Millisecond), WithDebug(&buf))
+	_, errc := Repeat(nil, 1, WithScope(sc))
+	sc.Go("idle", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	err := sc.Wait()
+	var te *ScopeTimeoutError
+	if !errors.As(err, &te) {
+		t.Fatalf("Wait: %v", err)
+	}
+	if strings.Join(te.Running, ",") != "Repeat,idle" {
+		t.Fatalf("running: %v", te.Running)
+	}
+	if dump := buf.String(); !strings.Contains(dump, "Repeat") || !strings.Contains(dump, "idle") {
+		t.Fatalf("dump: %q", dump)
+	}
+
+	sc.Cancel()
+	if err := sc.Wait(); err != nil {
+		t.Fatalf("Wait after Cancel: %v", err)
+	}
+	<-errc
+	waitGoroutines(t, before)
+}
+
+func TestScope_ParentCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sc := NewScope(ctx)
+	_, errc := Repeat(nil, 1, WithScope(sc))
+	cancel()
+
+	if err := sc.Wait(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait: %v", err)
+	}
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Repeat err: %v", err)
+	}
+}
+
+func TestScope_NilFunc(t *testing.T) {
+	t.Parallel()
+
+	sc := NewScope(nil)
+	sc.Go("nil", nil)
+	if err := sc.Wait(); err == nil {
+		t.Fatal("expected error")
+	}
+}
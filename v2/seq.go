@@ -0,0 +1,124 @@
+package v2
+
+import (
+	"context"
+	"iter"
+)
+
+// ChanToSeq adapts a (channel, error channel) pair to a range-over-func iterator.
+//
+// Every value is yielded with a nil error. Once in is closed, the single error from errc
+// (if non-nil) is yielded last. If ctx is done first, ctx.Err() is yielded instead.
+// errc may be nil if the producer reports no error.
+//
+// Breaking out of the loop early does not stop the producer; cancel its context
+// (or use Stream.All, which does so automatically).
+func ChanToSeq[T any](ctx context.Context, in <-chan T, errc <-chan error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		if in == nil {
+			yield(zero, errNilChan)
+			return
+		}
+		ctx = ensureCtx(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				yield(zero, ctx.Err())
+				return
+			case v, ok := <-in:
+				if !ok {
+					if errc == nil {
+						return
+					}
+					select {
+					case <-ctx.Done():
+						yield(zero, ctx.Err())
+					case err := <-errc:
+						if err != nil {
+							yield(zero, err)
+						}
+					}
+					return
+				}
+				if !yield(v, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// SeqToChan emits every value of seq on a channel.
+//
+// When ctx is canceled the iteration is stopped (seq's yield returns false), so seq can run
+// its cleanup. A consumer that stops reading early must cancel ctx.
+func SeqToChan[T any](ctx context.Context, seq iter.Seq[T], opts ...ChanOption) (<-chan T, <-chan error) {
+	if seq == nil {
+		return closedErrStream[T](ErrNilFunc("SeqToChan"))
+	}
+	cfg, err := chanCfg(opts)
+	if err != nil {
+		return closedErrStream[T](err)
+	}
+	out := make(chan T, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "SeqToChan", errc)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		if err := ctx.Err(); err != nil {
+			errc <- err
+			return
+		}
+		for v := range seq {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case out <- v:
+			}
+		}
+		errc <- nil
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+// All returns an iterator over the stream. The joined error of all stages (if any) is yielded last.
+// Breaking out of the loop early cancels every stage of the stream.
+func (s Stream[T]) All() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		if s.st == nil {
+			yield(zero, errNilStream)
+			return
+		}
+		var loopErr error
+	loop:
+		for {
+			select {
+			case <-s.st.ctx.Done():
+				loopErr = s.st.ctx.Err()
+				break loop
+			case v, ok := <-s.ch:
+				if !ok {
+					break loop
+				}
+				if !yield(v, nil) {
+					_ = s.finish()
+					return
+				}
+			}
+		}
+		if err := s.finish(); err != nil {
+			loopErr = err
+		}
+		if loopErr != nil {
+			yield(zero, loopErr)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+)
+
+func TestChanToSeq(t *testing.T) {
+	t.Parallel()
+
+	ch, errc := RangeN(context.Background(), 3)
+	var got []int
+	for v, err := range ChanToSeq(context.Background(), ch, errc) {
+		mustErr(t, err)
+		got = append(got, v)
+	}
+	if !intsEqual(got, []int{0, 1, 2}) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestChanToSeq_YieldsTerminalError(t *testing.T) {
+	t.Parallel()
+
+	sentinel := errors.New("boom")
+	ch, errc := Generate(context.Background(), GenFn[int](func(ctx context.Context) (int, bool, error) {
+		return 0, false, sentinel
+	}))
+	var errs []error
+	for _, err := range ChanToSeq(context.Background(), ch, errc) {
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], sentinel) {
+		t.Fatalf("errs=%v", errs)
+	}
+}
+
+func TestSeqToChan(t *testing.T) {
+	t.Parallel()
+
+	seq := func(yield func(string) bool) {
+		for _, s := range []string{"a", "b"} {
+			if !yield(s) {
+				return
+			}
+		}
+	}
+	ch, errc := SeqToChan(context.Background(), seq)
+	got := Collect(context.Background(), ch).Must()
+	mustErr(t, <-errc)
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestSeqToChan_EarlyBreakStopsProducer(t *testing.T) {
+	// Not parallel: counts goroutines.
+	before := runtime.NumGoroutine()
+
+	var cleanedUp atomic.Bool
+	naturals := func(yield func(int) bool) {
+		defer cleanedUp.Store(true)
+		for i := 0; ; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, errc := SeqToChan(ctx, naturals)
+	for v, err := range ChanToSeq(ctx, ch, errc) {
+		mustErr(t, err)
+		if v == 3 {
+			break
+		}
+	}
+	cancel()
+
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("err=%v, want context.Canceled", err)
+	}
+	if !cleanedUp.Load() {
+		t.Fatalf("producer iterator was not stopped")
+	}
+	waitGoroutines(t, before)
+}
+
+func TestStreamAll_EarlyBreakCancelsSource(t *testing.T) {
+	// Not parallel: counts goroutines.
+	before := runtime.NumGoroutine()
+
+	s := StreamFrom(context.Background(), func(ctx context.Context) (<-chan int, <-chan error) {
+		return Repeat(ctx, 1)
+	})
+	n := 0
+	for _, err := range s.Filter(func(int) bool { return true }).All() {
+		mustErr(t, err)
+		n++
+		if n == 2 {
+			break
+		}
+	}
+	waitGoroutines(t, before)
+}
+
+func TestStreamAll_YieldsError(t *testing.T) {
+	t.Parallel()
+
+	sentinel := errors.New("boom")
+	s := TryStream(StreamOf(context.Background(), 1, 2), TryFn[int, int](func(v int) (int, error) {
+		if v == 2 {
+			return 0, sentinel
+		}
+		return v, nil
+	}))
+	var last error
+	for _, err := range s.All() {
+		last = err
+	}
+	if !errors.Is(last, sentinel) {
+		t.Fatalf("last=%v, want %v", last, sentinel)
+	}
+}
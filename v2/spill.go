@@ -0,0 +1,298 @@
+package v2
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+var (
+	errInvalidMemLimit = errors.New("lambda/v2: memory limit must be >= 1")
+	errNilCodec        = errors.New("lambda/v2: nil codec")
+)
+
+// Codec encodes values of type T to a stream and decodes them back, in order.
+type Codec[T any] interface {
+	NewEncoder(w io.Writer) Encoder[T]
+	NewDecoder(r io.Reader) Decoder[T]
+}
+
+// Encoder writes values to a stream.
+type Encoder[T any] interface {
+	Encode(v T) error
+}
+
+// Decoder reads values from a stream. Decode returns io.EOF once the stream is exhausted.
+type Decoder[T any] interface {
+	Decode() (T, error)
+}
+
+// JSONCodec encodes values as newline-delimited JSON.
+type JSONCodec[T any] struct{}
+
+// NewEncoder returns a JSON encoder writing to w.
+func (JSONCodec[T]) NewEncoder(w io.Writer) Encoder[T] { return jsonEncoder[T]{json.NewEncoder(w)} }
+
+// NewDecoder returns a JSON decoder reading from r.
+func (JSONCodec[T]) NewDecoder(r io.Reader) Decoder[T] { return jsonDecoder[T]{json.NewDecoder(r)} }
+
+type jsonEncoder[T any] struct{ enc *json.Encoder }
+
+func (e jsonEncoder[T]) Encode(v T) error { return e.enc.Encode(v) }
+
+type jsonDecoder[T any] struct{ dec *json.Decoder }
+
+func (d jsonDecoder[T]) Decode() (T, error) {
+	var v T
+	err := d.dec.Decode(&v)
+	return v, err
+}
+
+// GobCodec encodes values with encoding/gob. It is faster than JSONCodec and keeps Go types
+// intact, but T must be gob-encodable (exported fields, registered interface types).
+type GobCodec[T any] struct{}
+
+// NewEncoder returns a gob encoder writing to w.
+func (GobCodec[T]) NewEncoder(w io.Writer) Encoder[T] { return gobEncoder[T]{gob.NewEncoder(w)} }
+
+// NewDecoder returns a gob decoder reading from r.
+func (GobCodec[T]) NewDecoder(r io.Reader) Decoder[T] { return gobDecoder[T]{gob.NewDecoder(r)} }
+
+type gobEncoder[T any] struct{ enc *gob.Encoder }
+
+func (e gobEncoder[T]) Encode(v T) error { return e.enc.Encode(&v) }
+
+type gobDecoder[T any] struct{ dec *gob.Decoder }
+
+func (d gobDecoder[T]) Decode() (T, error) {
+	var v T
+	err := d.dec.Decode(&v)
+	return v, err
+}
+
+// segment is a file of encoded values. It is written once, then read once and removed.
+type segment[T any] struct {
+	path string
+	n    int // values written and not yet read
+
+	f   *os.File
+	w   *bufio.Writer
+	enc Encoder[T]
+	dec Decoder[T]
+}
+
+func createSegment[T any](dir string, seq int, codec Codec[T]) (*segment[T], error) {
+	path := filepath.Join(dir, fmt.Sprintf("segment-%06d", seq))
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	return &segment[T]{path: path, f: f, w: w, enc: codec.NewEncoder(w)}, nil
+}
+
+func (s *segment[T]) write(v T) error {
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	s.n++
+	return nil
+}
+
+// seal finishes writing and reopens the segment for reading.
+func (s *segment[T]) seal(codec Codec[T]) error {
+	if s.w == nil {
+		return nil
+	}
+	err := errors.Join(s.w.Flush(), s.f.Close())
+	s.w, s.enc = nil, nil
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	s.f = f
+	s.dec = codec.NewDecoder(bufio.NewReader(f))
+	return nil
+}
+
+func (s *segment[T]) read() (T, error) {
+	v, err := s.dec.Decode()
+	if err == nil {
+		s.n--
+	} else if errors.Is(err, io.EOF) {
+		err = fmt.Errorf("lambda/v2: spill segment %s truncated: %w", s.path, io.ErrUnexpectedEOF)
+	}
+	return v, err
+}
+
+func (s *segment[T]) remove() error {
+	var err error
+	if s.f != nil {
+		err = s.f.Close()
+		s.f = nil
+	}
+	return errors.Join(err, os.Remove(s.path))
+}
+
+// ring is a fixed-size FIFO queue.
+type ring[T any] struct {
+	buf     []T
+	head, n int
+}
+
+func newRing[T any](size int) *ring[T] { return &ring[T]{buf: make([]T, size)} }
+
+func (r *ring[T]) full() bool { return r.n == len(r.buf) }
+func (r *ring[T]) peek() T    { return r.buf[r.head] }
+
+func (r *ring[T]) push(v T) {
+	r.buf[(r.head+r.n)%len(r.buf)] = v
+	r.n++
+}
+
+func (r *ring[T]) pop() {
+	var zero T
+	r.buf[r.head] = zero
+	r.head = (r.head + 1) % len(r.buf)
+	r.n--
+}
+
+// SpillBuffer buffers values from in without blocking the producer: up to memLimit values are
+// kept in memory, the overflow is written to segment files in a temporary directory below dir
+// (os.TempDir() if empty) using codec, e.g. JSONCodec or GobCodec.
+//
+// Values are emitted in order. Segments are deleted once consumed and the directory is
+// removed when the stage ends. SortChan's WithMemoryLimit and WithTempDir are rejected; pass
+// memLimit and dir instead.
+func SpillBuffer[T any](ctx context.Context, in <-chan T, memLimit int, dir string, codec Codec[T], opts ...ChanOption) (<-chan T, <-chan error) {
+	if in == nil {
+		return closedErrStream[T](errNilChan)
+	}
+	if memLimit < 1 {
+		return closedErrStream[T](errInvalidMemLimit)
+	}
+	if codec == nil {
+		return closedErrStream[T](errNilCodec)
+	}
+	cfg, err := chanCfg(opts)
+	if err != nil {
+		return closedErrStream[T](err)
+	}
+	out := make(chan T, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "SpillBuffer", errc)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		tmp, err := os.MkdirTemp(dir, "lambda-spill-")
+		if err != nil {
+			errc <- err
+			return
+		}
+
+		var (
+			mem  = newRing[T](memLimit)
+			segs []*segment[T] // oldest first; only the last one may still be written
+			seq  int
+			src  = in
+		)
+		cleanup := func() error {
+			var errs []error
+			for _, s := range segs {
+				if s.w != nil {
+					s.w.Flush()
+				}
+				errs = append(errs, s.remove())
+			}
+			return errors.Join(append(errs, os.RemoveAll(tmp))...)
+		}
+		fail := func(err error) {
+			errc <- errors.Join(err, cleanup())
+		}
+
+		// push adds v behind everything buffered so far.
+		push := func(v T) error {
+			if len(segs) == 0 && !mem.full() {
+				mem.push(v)
+				return nil
+			}
+			if len(segs) == 0 || segs[len(segs)-1].w == nil || segs[len(segs)-1].n >= memLimit {
+				s, err := createSegment(tmp, seq, codec)
+				if err != nil {
+					return err
+				}
+				seq++
+				segs = append(segs, s)
+			}
+			return segs[len(segs)-1].write(v)
+		}
+		// refill moves values from the oldest segment back into memory.
+		refill := func() error {
+			s := segs[0]
+			if err := s.seal(codec); err != nil {
+				return err
+			}
+			for s.n > 0 && !mem.full() {
+				v, err := s.read()
+				if err != nil {
+					return err
+				}
+				mem.push(v)
+			}
+			if s.n == 0 {
+				segs[0] = nil
+				segs = segs[1:]
+				return s.remove()
+			}
+			return nil
+		}
+
+		for src != nil || mem.n > 0 || len(segs) > 0 {
+			if mem.n == 0 && len(segs) > 0 {
+				if err := refill(); err != nil {
+					fail(err)
+					return
+				}
+			}
+			var (
+				sendC chan<- T
+				next  T
+			)
+			if mem.n > 0 {
+				sendC, next = out, mem.peek()
+			}
+			select {
+			case <-ctx.Done():
+				fail(ctx.Err())
+				return
+			case v, ok := <-src:
+				if !ok {
+					src = nil
+					continue
+				}
+				if err := push(v); err != nil {
+					fail(err)
+					return
+				}
+			case sendC <- next:
+				mem.pop()
+			}
+		}
+		errc <- cleanup()
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
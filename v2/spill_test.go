@@ -0,0 +1,154 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// spillSegments returns the segment files currently below dir.
+func spillSegments(t *testing.T, dir string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, "lambda-spill-*", "segment-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+func TestSpillBuffer_OrderAndCleanup(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	in := make(chan int)
+	out, errc := SpillBuffer(context.Background(), in, 4, dir, JSONCodec[int]{})
+
+	// The producer never blocks, even though nobody reads yet.
+	want := make([]int, 50)
+	for i := range want {
+		want[i] = i
+		select {
+		case in <- i:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("producer blocked at %d", i)
+		}
+	}
+	close(in)
+	if segs := spillSegments(t, dir); len(segs) == 0 {
+		t.Fatal("expected spilled segments")
+	}
+
+	got := Collect(nil, out).Must()
+	mustErr(t, <-errc)
+	if !intsEqual(got, want) {
+		t.Fatalf("got %v", got)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("left behind: %v", entries)
+	}
+}
+
+func TestSpillBuffer_Interleaved(t *testing.T) {
+	t.Parallel()
+
+	in := make(chan int)
+	out, errc := SpillBuffer(context.Background(), in, 2, t.TempDir(), GobCodec[int]{})
+
+	var got []int
+	next := 0
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 7; i++ {
+			in <- next
+			next++
+		}
+		for i := 0; i < 3; i++ {
+			got = append(got, <-out)
+		}
+	}
+	close(in)
+	got = append(got, Collect(nil, out).Must()...)
+	mustErr(t, <-errc)
+
+	if len(got) != next {
+		t.Fatalf("got %d values, want %d", len(got), next)
+	}
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("got[%d] = %d", i, v)
+		}
+	}
+}
+
+type spillEvent struct {
+	ID   int
+	Tags []string
+}
+
+func TestSpillBuffer_GobStructs(t *testing.T) {
+	t.Parallel()
+
+	events := []spillEvent{{1, []string{"a"}}, {2, nil}, {3, []string{"b", "c"}}, {4, []string{"d"}}}
+	src, srcErrc := FromSlice(context.Background(), events)
+	out, errc := SpillBuffer(context.Background(), src, 1, t.TempDir(), GobCodec[spillEvent]{})
+
+	time.Sleep(10 * time.Millisecond) // let the stage spill
+	got := Collect(nil, out).Must()
+	mustErr(t, JoinErr(srcErrc, errc))
+	if len(got) != len(events) {
+		t.Fatalf("got %v", got)
+	}
+	for i := range got {
+		if got[i].ID != events[i].ID || len(got[i].Tags) != len(events[i].Tags) {
+			t.Fatalf("got[%d] = %+v", i, got[i])
+		}
+	}
+}
+
+func TestSpillBuffer_CancelRemovesSegments(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	out, errc := SpillBuffer(ctx, in, 1, dir, JSONCodec[int]{})
+	for i := 0; i < 10; i++ {
+		in <- i
+	}
+	cancel()
+	Drain(nil, out)
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v", err)
+	}
+	if segs := spillSegments(t, dir); len(segs) != 0 {
+		t.Fatalf("left behind: %v", segs)
+	}
+}
+
+func TestSpillBuffer_InvalidArgs(t *testing.T) {
+	t.Parallel()
+
+	in := make(chan int)
+	if _, errc := SpillBuffer[int](nil, nil, 1, "", JSONCodec[int]{}); !errors.Is(<-errc, errNilChan) {
+		t.Fatal("expected errNilChan")
+	}
+	if _, errc := SpillBuffer(nil, in, 0, "", JSONCodec[int]{}); !errors.Is(<-errc, errInvalidMemLimit) {
+		t.Fatal("expected errInvalidMemLimit")
+	}
+	if _, errc := SpillBuffer[int](nil, in, 1, "", nil); !errors.Is(<-errc, errNilCodec) {
+		t.Fatal("expected errNilCodec")
+	}
+	if _, errc := SpillBuffer(nil, in, 1, "", JSONCodec[int]{}, WithMemoryLimit(10)); !errors.Is(<-errc, errUnsupportedOption) {
+		t.Fatal("expected errUnsupportedOption")
+	}
+	close(in)
+	if _, errc := SpillBuffer(nil, in, 1, filepath.Join(t.TempDir(), "missing"), JSONCodec[int]{}); <-errc == nil {
+		t.Fatal("expected error for missing dir")
+	}
+}
@@ -0,0 +1,468 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"math/rand/v2"
+	"sort"
+)
+
+// Accumulator is a streaming aggregate that values can be added to one at a time.
+// RunningStats, TDigest, Histogram, Reservoir and HyperLogLog are accumulators.
+type Accumulator[T any] interface {
+	Add(v T)
+}
+
+// Accumulate adds every value from in to acc and returns acc once in is closed.
+func Accumulate[T any, A Accumulator[T]](ctx context.Context, in <-chan T, acc A) Option[A] {
+	if in == nil {
+		return Err[A](errNilChan)
+	}
+	ctx = ensureCtx(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return Err[A](ctx.Err())
+		case v, ok := <-in:
+			if !ok {
+				return Ok(acc)
+			}
+			acc.Add(v)
+		}
+	}
+}
+
+// AccumulateWith returns an AggFn (e.g. for Window) that adds a window's values to a fresh
+// accumulator created by newAcc.
+func AccumulateWith[T any, A Accumulator[T]](newAcc func() A) AggFn[T, A] {
+	return func(xs []T) A {
+		acc := newAcc()
+		for _, x := range xs {
+			acc.Add(x)
+		}
+		return acc
+	}
+}
+
+// RunningStats tracks count, mean, variance, min and max of a stream (Welford's algorithm).
+// The zero value is ready to use.
+type RunningStats struct {
+	n        int64
+	mean, m2 float64
+	min, max float64
+}
+
+// Add adds x.
+func (s *RunningStats) Add(x float64) {
+	s.n++
+	if s.n == 1 {
+		s.min, s.max = x, x
+	} else {
+		s.min, s.max = math.Min(s.min, x), math.Max(s.max, x)
+	}
+	d := x - s.mean
+	s.mean += d / float64(s.n)
+	s.m2 += d * (x - s.mean)
+}
+
+// Merge adds the values summarized by o.
+func (s *RunningStats) Merge(o *RunningStats) {
+	if o == nil || o.n == 0 {
+		return
+	}
+	if s.n == 0 {
+		*s = *o
+		return
+	}
+	n := s.n + o.n
+	d := o.mean - s.mean
+	s.mean += d * float64(o.n) / float64(n)
+	s.m2 += o.m2 + d*d*float64(s.n)*float64(o.n)/float64(n)
+	s.min, s.max = math.Min(s.min, o.min), math.Max(s.max, o.max)
+	s.n = n
+}
+
+// Count returns the number of values added.
+func (s *RunningStats) Count() int64 { return s.n }
+
+// Mean returns the mean (0 if empty).
+func (s *RunningStats) Mean() float64 { return s.mean }
+
+// Variance returns the sample variance (0 for fewer than two values).
+func (s *RunningStats) Variance() float64 {
+	if s.n < 2 {
+		return 0
+	}
+	return s.m2 / float64(s.n-1)
+}
+
+// StdDev returns the sample standard deviation.
+func (s *RunningStats) StdDev() float64 { return math.Sqrt(s.Variance()) }
+
+// Min returns the smallest value (0 if empty).
+func (s *RunningStats) Min() float64 { return s.min }
+
+// Max returns the largest value (0 if empty).
+func (s *RunningStats) Max() float64 { return s.max }
+
+type centroid struct {
+	mean, weight float64
+}
+
+// TDigest is a merging t-digest: a compact sketch that estimates quantiles of a stream, most
+// accurately near the tails.
+type TDigest struct {
+	compression float64
+	centroids   []centroid // sorted by mean
+	buf         []centroid
+	count       float64
+	min, max    float64
+}
+
+// NewTDigest creates a t-digest. Higher compression is more accurate and uses more memory;
+// 100 is a good default. Values below 20 are raised to 20.
+func NewTDigest(compression float64) *TDigest {
+	if compression < 20 {
+		compression = 20
+	}
+	return &TDigest{compression: compression, min: math.Inf(1), max: math.Inf(-1)}
+}
+
+// Add adds x.
+func (d *TDigest) Add(x float64) {
+	if math.IsNaN(x) {
+		return
+	}
+	d.addCentroid(centroid{mean: x, weight: 1})
+	d.min, d.max = math.Min(d.min, x), math.Max(d.max, x)
+}
+
+func (d *TDigest) addCentroid(c centroid) {
+	d.buf = append(d.buf, c)
+	d.count += c.weight
+	if len(d.buf) >= int(5*d.compression) {
+		d.compress()
+	}
+}
+
+// Merge adds the values summarized by o.
+func (d *TDigest) Merge(o *TDigest) {
+	if o == nil || o.count == 0 {
+		return
+	}
+	for _, c := range o.centroids {
+		d.addCentroid(c)
+	}
+	for _, c := range o.buf {
+		d.addCentroid(c)
+	}
+	d.min, d.max = math.Min(d.min, o.min), math.Max(d.max, o.max)
+}
+
+func (d *TDigest) compress() {
+	if len(d.buf) == 0 {
+		return
+	}
+	all := append(d.centroids, d.buf...)
+	d.buf = d.buf[:0]
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+
+	merged := make([]centroid, 0, len(all))
+	cur := all[0]
+	cum := 0.0
+	for _, c := range all[1:] {
+		w := cur.weight + c.weight
+		q := (cum + w/2) / d.count
+		if w <= math.Max(1, 4*d.count*q*(1-q)/d.compression) {
+			cur.mean += (c.mean - cur.mean) * c.weight / w
+			cur.weight = w
+			continue
+		}
+		merged = append(merged, cur)
+		cum += cur.weight
+		cur = c
+	}
+	d.centroids = append(merged, cur)
+}
+
+// Count returns the number of values added.
+func (d *TDigest) Count() int64 { return int64(d.count) }
+
+// Quantile estimates the q-quantile (0 <= q <= 1). It returns NaN if the digest is empty.
+func (d *TDigest) Quantile(q float64) float64 {
+	d.compress()
+	if len(d.centroids) == 0 || q < 0 || q > 1 {
+		return math.NaN()
+	}
+	if len(d.centroids) == 1 {
+		return d.centroids[0].mean
+	}
+	target := q * d.count
+	cs := d.centroids
+	first := cs[0].weight / 2
+	if target <= first {
+		return d.min + (cs[0].mean-d.min)*target/first
+	}
+	cum := 0.0
+	for i := 0; i < len(cs)-1; i++ {
+		left := cum + cs[i].weight/2
+		right := cum + cs[i].weight + cs[i+1].weight/2
+		if target <= right {
+			return cs[i].mean + (cs[i+1].mean-cs[i].mean)*(target-left)/(right-left)
+		}
+		cum += cs[i].weight
+	}
+	last := cs[len(cs)-1]
+	lastCenter := d.count - last.weight/2
+	if d.count == lastCenter {
+		return d.max
+	}
+	return last.mean + (d.max-last.mean)*(target-lastCenter)/(d.count-lastCenter)
+}
+
+var errBucketMismatch = errors.New("lambda/v2: histogram buckets differ")
+
+// Histogram counts values in fixed buckets. Bucket i counts values <= Bounds[i] (and greater
+// than the previous bound); a final overflow bucket counts values above the last bound.
+type Histogram struct {
+	bounds []float64
+	counts []int64
+	stats  RunningStats
+}
+
+// Bucket is one histogram bucket: Count values <= Upper. The overflow bucket has Upper = +Inf.
+type Bucket struct {
+	Upper float64
+	Count int64
+}
+
+// NewHistogram creates a histogram with the given upper bucket bounds (sorted, deduplicated).
+func NewHistogram(bounds ...float64) *Histogram {
+	bs := append([]float64(nil), bounds...)
+	sort.Float64s(bs)
+	uniq := bs[:0]
+	for i, b := range bs {
+		if i == 0 || b != bs[i-1] {
+			uniq = append(uniq, b)
+		}
+	}
+	return &Histogram{bounds: uniq, counts: make([]int64, len(uniq)+1)}
+}
+
+// LinearBuckets returns n bounds start, start+width, ...
+func LinearBuckets(start, width float64, n int) []float64 {
+	bs := make([]float64, 0, n)
+	for i := 0; i < n; i++ {
+		bs = append(bs, start+float64(i)*width)
+	}
+	return bs
+}
+
+// ExponentialBuckets returns n bounds start, start*factor, start*factor^2, ...
+func ExponentialBuckets(start, factor float64, n int) []float64 {
+	bs := make([]float64, 0, n)
+	for i, b := 0, start; i < n; i, b = i+1, b*factor {
+		bs = append(bs, b)
+	}
+	return bs
+}
+
+// Add adds x.
+func (h *Histogram) Add(x float64) {
+	h.counts[sort.SearchFloat64s(h.bounds, x)]++
+	h.stats.Add(x)
+}
+
+// Merge adds the counts of o. Both histograms must have the same bounds.
+func (h *Histogram) Merge(o *Histogram) error {
+	if o == nil {
+		return nil
+	}
+	if len(o.bounds) != len(h.bounds) {
+		return errBucketMismatch
+	}
+	for i := range h.bounds {
+		if h.bounds[i] != o.bounds[i] {
+			return errBucketMismatch
+		}
+	}
+	for i := range h.counts {
+		h.counts[i] += o.counts[i]
+	}
+	h.stats.Merge(&o.stats)
+	return nil
+}
+
+// Buckets returns every bucket including the overflow bucket.
+func (h *Histogram) Buckets() []Bucket {
+	out := make([]Bucket, len(h.counts))
+	for i, c := range h.counts {
+		upper := math.Inf(1)
+		if i < len(h.bounds) {
+			upper = h.bounds[i]
+		}
+		out[i] = Bucket{Upper: upper, Count: c}
+	}
+	return out
+}
+
+// Stats returns count, mean, variance, min and max of the values added.
+func (h *Histogram) Stats() RunningStats { return h.stats }
+
+// Reservoir keeps a uniform random sample of up to k values of a stream (Algorithm R).
+type Reservoir[T any] struct {
+	k      int
+	seen   int64
+	sample []T
+	rng    *rand.Rand
+}
+
+// NewReservoir creates a reservoir of size k (at least 1) whose RNG is seeded with seed,
+// so samples are reproducible.
+func NewReservoir[T any](k int, seed uint64) *Reservoir[T] {
+	if k < 1 {
+		k = 1
+	}
+	return &Reservoir[T]{k: k, sample: make([]T, 0, k), rng: rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))}
+}
+
+// Add offers v to the sample.
+func (r *Reservoir[T]) Add(v T) {
+	r.seen++
+	if len(r.sample) < r.k {
+		r.sample = append(r.sample, v)
+		return
+	}
+	if j := r.rng.Int64N(r.seen); j < int64(r.k) {
+		r.sample[j] = v
+	}
+}
+
+// Merge combines o into r so that r samples the union of both streams.
+// Each value of the result is drawn from r or o in proportion to the values they have seen.
+func (r *Reservoir[T]) Merge(o *Reservoir[T]) {
+	if o == nil || o.seen == 0 {
+		return
+	}
+	if o.seen == int64(len(o.sample)) {
+		// o kept every value it saw: replaying them is exact.
+		for _, v := range o.sample {
+			r.Add(v)
+		}
+		return
+	}
+	a := append([]T(nil), r.sample...)
+	b := append([]T(nil), o.sample...)
+	na, nb := r.seen, o.seen
+	merged := make([]T, 0, r.k)
+	for len(merged) < r.k && (len(a) > 0 || len(b) > 0) {
+		src := &a
+		if len(a) == 0 || (len(b) > 0 && r.rng.Int64N(na+nb) >= na) {
+			src = &b
+		}
+		i := r.rng.IntN(len(*src))
+		merged = append(merged, (*src)[i])
+		(*src)[i] = (*src)[len(*src)-1]
+		*src = (*src)[:len(*src)-1]
+	}
+	r.sample = merged
+	r.seen = na + nb
+}
+
+// Sample returns a copy of the current sample.
+func (r *Reservoir[T]) Sample() []T { return append([]T(nil), r.sample...) }
+
+// Seen returns the number of values offered.
+func (r *Reservoir[T]) Seen() int64 { return r.seen }
+
+// HyperLogLog estimates the number of distinct values of a stream in fixed memory
+// (2^precision bytes) with a standard error of about 1.04/sqrt(2^precision).
+type HyperLogLog struct {
+	p    uint8
+	regs []uint8
+}
+
+// NewHyperLogLog creates a sketch with the given precision (4..18).
+func NewHyperLogLog(precision uint8) (*HyperLogLog, error) {
+	if precision < 4 || precision > 18 {
+		return nil, fmt.Errorf("lambda/v2: hyperloglog precision %d out of range 4..18", precision)
+	}
+	return &HyperLogLog{p: precision, regs: make([]uint8, 1<<precision)}, nil
+}
+
+// hash64 is FNV-1a followed by a 64-bit finalizer, so every bit depends on every input byte.
+func hash64(b []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(b)
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// Add adds a value.
+func (h *HyperLogLog) Add(b []byte) {
+	x := hash64(b)
+	idx := x >> (64 - h.p)
+	rank := uint8(bits.LeadingZeros64(x<<h.p|1<<(h.p-1))) + 1
+	if rank > h.regs[idx] {
+		h.regs[idx] = rank
+	}
+}
+
+// AddString adds a string value.
+func (h *HyperLogLog) AddString(s string) { h.Add([]byte(s)) }
+
+// Merge combines o into h. Both sketches must have the same precision.
+func (h *HyperLogLog) Merge(o *HyperLogLog) error {
+	if o == nil {
+		return nil
+	}
+	if o.p != h.p {
+		return fmt.Errorf("lambda/v2: hyperloglog precision mismatch (%d vs %d)", h.p, o.p)
+	}
+	for i, r := range o.regs {
+		if r > h.regs[i] {
+			h.regs[i] = r
+		}
+	}
+	return nil
+}
+
+// Count returns the estimated number of distinct values.
+func (h *HyperLogLog) Count() uint64 {
+	m := float64(len(h.regs))
+	var (
+		sum   float64
+		zeros int
+	)
+	for _, r := range h.regs {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	var alpha float64
+	switch len(h.regs) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+	est := alpha * m * m / sum
+	if est <= 2.5*m && zeros > 0 {
+		est = m * math.Log(m/float64(zeros))
+	}
+	return uint64(est + 0.5)
+}
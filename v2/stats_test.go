@@ -0,0 +1,192 @@
+package v2
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestRunningStats_Merge(t *testing.T) {
+	t.Parallel()
+
+	var all, a, b RunningStats
+	for i := 1; i <= 100; i++ {
+		x := float64(i)
+		all.Add(x)
+		if i%3 == 0 {
+			a.Add(x)
+		} else {
+			b.Add(x)
+		}
+	}
+	a.Merge(&b)
+	if a.Count() != 100 || a.Min() != 1 || a.Max() != 100 {
+		t.Fatalf("count=%d min=%v max=%v", a.Count(), a.Min(), a.Max())
+	}
+	if math.Abs(a.Mean()-50.5) > 1e-9 || math.Abs(a.Variance()-all.Variance()) > 1e-9 {
+		t.Fatalf("mean=%v var=%v want var %v", a.Mean(), a.Variance(), all.Variance())
+	}
+	if math.Abs(all.Variance()-841.6666666666666) > 1e-6 {
+		t.Fatalf("var = %v", all.Variance())
+	}
+}
+
+func TestTDigest_Quantiles(t *testing.T) {
+	t.Parallel()
+
+	rng := rand.New(rand.NewSource(1))
+	parts := []*TDigest{NewTDigest(100), NewTDigest(100), NewTDigest(100)}
+	for i, p := range rng.Perm(100_000) {
+		parts[i%3].Add(float64(p))
+	}
+	d := NewTDigest(100)
+	for _, p := range parts {
+		d.Merge(p)
+	}
+	if d.Count() != 100_000 {
+		t.Fatalf("count = %d", d.Count())
+	}
+	for _, q := range []float64{0.01, 0.25, 0.5, 0.9, 0.99} {
+		want := q * 100_000
+		if got := d.Quantile(q); math.Abs(got-want) > 500 {
+			t.Errorf("q%.2f = %v, want ~%v", q, got, want)
+		}
+	}
+	if d.Quantile(0) != 0 || d.Quantile(1) != 99_999 {
+		t.Fatalf("extremes: %v %v", d.Quantile(0), d.Quantile(1))
+	}
+	if !math.IsNaN(NewTDigest(100).Quantile(0.5)) {
+		t.Fatal("expected NaN for empty digest")
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	t.Parallel()
+
+	h := NewHistogram(LinearBuckets(10, 10, 3)...)
+	for _, x := range []float64{1, 10, 11, 25, 30, 31, 100} {
+		h.Add(x)
+	}
+	other := NewHistogram(10, 20, 30)
+	other.Add(5)
+	if err := h.Merge(other); err != nil {
+		t.Fatal(err)
+	}
+	var counts []int
+	for _, b := range h.Buckets() {
+		counts = append(counts, int(b.Count))
+	}
+	if !intsEqual(counts, []int{3, 1, 2, 2}) {
+		t.Fatalf("counts = %v", counts)
+	}
+	if s := h.Stats(); s.Count() != 8 || s.Max() != 100 {
+		t.Fatalf("stats = %+v", s)
+	}
+	if err := h.Merge(NewHistogram(ExponentialBuckets(1, 2, 3)...)); err == nil {
+		t.Fatal("expected error for different buckets")
+	}
+}
+
+func TestReservoir(t *testing.T) {
+	t.Parallel()
+
+	sample := func(seed uint64) []int {
+		r := NewReservoir[int](10, seed)
+		for i := 0; i < 1000; i++ {
+			r.Add(i)
+		}
+		return r.Sample()
+	}
+	if !intsEqual(sample(7), sample(7)) {
+		t.Fatal("same seed gave different samples")
+	}
+	if len(sample(7)) != 10 {
+		t.Fatalf("sample = %v", sample(7))
+	}
+
+	// Merging a large stream with a small one picks mostly from the large one.
+	fromA := 0
+	for seed := uint64(0); seed < 50; seed++ {
+		a, b := NewReservoir[int](10, seed), NewReservoir[int](10, seed+100)
+		for i := 0; i < 900; i++ {
+			a.Add(i)
+		}
+		for i := 0; i < 100; i++ {
+			b.Add(1000 + i)
+		}
+		a.Merge(b)
+		if a.Seen() != 1000 || len(a.Sample()) != 10 {
+			t.Fatalf("seen=%d sample=%v", a.Seen(), a.Sample())
+		}
+		for _, v := range a.Sample() {
+			if v < 1000 {
+				fromA++
+			}
+		}
+	}
+	if fromA < 400 || fromA > 490 {
+		t.Fatalf("%d of 500 values from the larger stream", fromA)
+	}
+
+	// A reservoir that kept everything is replayed exactly.
+	a, b := NewReservoir[int](5, 1), NewReservoir[int](5, 2)
+	a.Add(1)
+	b.Add(2)
+	b.Add(3)
+	a.Merge(b)
+	if !intsEqual(a.Sample(), []int{1, 2, 3}) {
+		t.Fatalf("sample = %v", a.Sample())
+	}
+}
+
+func TestHyperLogLog(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewHyperLogLog(3); err == nil {
+		t.Fatal("expected error for precision 3")
+	}
+	a, _ := NewHyperLogLog(14)
+	b, _ := NewHyperLogLog(14)
+	for i := 0; i < 60_000; i++ {
+		a.AddString(strconv.Itoa(i))
+	}
+	for i := 40_000; i < 100_000; i++ {
+		b.AddString(strconv.Itoa(i))
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if n := a.Count(); math.Abs(float64(n)-100_000) > 3_000 {
+		t.Fatalf("count = %d", n)
+	}
+
+	small, _ := NewHyperLogLog(10)
+	for i := 0; i < 3; i++ {
+		small.AddString("x")
+		small.AddString("y")
+	}
+	if n := small.Count(); n != 2 {
+		t.Fatalf("count = %d", n)
+	}
+	if err := small.Merge(a); err == nil {
+		t.Fatal("expected precision mismatch")
+	}
+}
+
+func TestAccumulate(t *testing.T) {
+	t.Parallel()
+
+	src, errc := FromSlice(context.Background(), []float64{2, 4, 6})
+	s := Accumulate(context.Background(), src, &RunningStats{}).Must()
+	mustErr(t, <-errc)
+	if s.Mean() != 4 || s.Count() != 3 {
+		t.Fatalf("mean=%v count=%d", s.Mean(), s.Count())
+	}
+
+	agg := AccumulateWith[float64](func() *TDigest { return NewTDigest(50) })
+	if got := agg([]float64{1, 2, 3, 4, 5}).Quantile(0.5); got != 3 {
+		t.Fatalf("median = %v", got)
+	}
+}
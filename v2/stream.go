@@ -0,0 +1,425 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+var (
+	errNilStream  = errors.New("lambda/v2: stream is not initialized")
+	errStreamDone = errors.New("lambda/v2: stream done")
+)
+
+// streamStage tracks the single error reported by one stage of a Stream.
+type streamStage struct {
+	done chan struct{}
+	err  error
+}
+
+// streamState is shared by every stage (and every Tee branch) of a Stream.
+type streamState struct {
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	mu       sync.Mutex
+	branches int
+}
+
+// Stream bundles a channel with the error channels of every stage that produced it.
+//
+// Type-preserving stages are methods (Take, Drop, Filter, ...), type-changing stages are
+// generic functions (MapStream, TryStream, ...). Errors of all stages are merged and
+// reported once by a terminal operation (Collect, Drain, ForEach, Chan).
+//
+// The first stage error cancels all other stages. A terminal operation always cancels the
+// remaining stages once it returns, so stopping early (e.g. via Take) does not leak goroutines
+// as long as the source observes the stream's context (see StreamFrom).
+//
+// A Stream value must be consumed exactly once.
+type Stream[T any] struct {
+	st     *streamState
+	ch     <-chan T
+	stages []*streamStage
+}
+
+// NewStream adopts an existing (channel, error channel) pair as a Stream.
+//
+// The producer of ch does not know about the stream's internal context, so it is only
+// stopped early if it observes ctx. Prefer StreamFrom for leak-free early termination.
+func NewStream[T any](ctx context.Context, ch <-chan T, errc <-chan error) Stream[T] {
+	ctx = ensureCtx(ctx)
+	sctx, cancel := context.WithCancelCause(ctx)
+	st := &streamState{parent: ctx, ctx: sctx, cancel: cancel, branches: 1}
+	if ch == nil {
+		ch, errc = closedErrStream[T](errNilChan)
+	}
+	return Stream[T]{st: st, ch: ch, stages: []*streamStage{st.watch(errc)}}
+}
+
+// StreamFrom builds a Stream from a source constructor. src receives the stream's
+// internal context, which is canceled on the first error or once a terminal returns.
+func StreamFrom[T any](ctx context.Context, src func(context.Context) (<-chan T, <-chan error)) Stream[T] {
+	if src == nil {
+		ch, errc := closedErrStream[T](ErrNilFunc("StreamFrom"))
+		return NewStream(ctx, ch, errc)
+	}
+	ctx = ensureCtx(ctx)
+	sctx, cancel := context.WithCancelCause(ctx)
+	st := &streamState{parent: ctx, ctx: sctx, cancel: cancel, branches: 1}
+	ch, errc := src(sctx)
+	if ch == nil {
+		ch, errc = closedErrStream[T](errNilChan)
+	}
+	return Stream[T]{st: st, ch: ch, stages: []*streamStage{st.watch(errc)}}
+}
+
+// StreamOf builds a Stream that emits xs.
+func StreamOf[T any](ctx context.Context, xs ...T) Stream[T] {
+	return StreamFrom(ctx, func(ctx context.Context) (<-chan T, <-chan error) {
+		return FromSlice(ctx, xs)
+	})
+}
+
+// watch reads the single error of a stage and cancels the stream if it is a real failure.
+func (st *streamState) watch(errc <-chan error) *streamStage {
+	stage := &streamStage{done: make(chan struct{})}
+	go func() {
+		defer close(stage.done)
+		if errc == nil {
+			stage.err = errNilErrChan
+		} else if err, ok := <-errc; ok {
+			stage.err = err
+		}
+		if stage.err != nil && !isCtxErr(stage.err) {
+			st.cancel(stage.err)
+		}
+	}()
+	return stage
+}
+
+// release marks one branch as finished. The last branch cancels the remaining stages.
+func (st *streamState) release() {
+	st.mu.Lock()
+	st.branches--
+	last := st.branches <= 0
+	st.mu.Unlock()
+	if last {
+		st.cancel(errStreamDone)
+	}
+}
+
+func isCtxErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// then appends a stage (ch, errc) to the lineage of s.
+func then[T, U any](s Stream[T], ch <-chan U, errc <-chan error) Stream[U] {
+	stages := make([]*streamStage, len(s.stages), len(s.stages)+1)
+	copy(stages, s.stages)
+	stages = append(stages, s.st.watch(errc))
+	return Stream[U]{st: s.st, ch: ch, stages: stages}
+}
+
+// finish releases the branch, waits for every stage in its lineage and joins their errors.
+func (s Stream[T]) finish() error {
+	s.st.release()
+
+	var (
+		errs        []error
+		interrupted bool
+	)
+	for _, stage := range s.stages {
+		<-stage.done
+		switch {
+		case stage.err == nil:
+		case isCtxErr(stage.err):
+			interrupted = true
+		default:
+			errs = append(errs, stage.err)
+		}
+	}
+	if s.st.ctx.Err() != nil && s.st.parent.Err() != nil {
+		interrupted = true
+	}
+	if interrupted {
+		cause := context.Cause(s.st.ctx)
+		if cause != nil && cause != errStreamDone && !containsErr(errs, cause) {
+			errs = append(errs, cause)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func containsErr(errs []error, err error) bool {
+	for _, e := range errs {
+		if e == err {
+			return true
+		}
+	}
+	return false
+}
+
+// Context returns the stream's internal context.
+func (s Stream[T]) Context() context.Context {
+	if s.st == nil {
+		return context.Background()
+	}
+	return s.st.ctx
+}
+
+// Take keeps only the first n values.
+func (s Stream[T]) Take(n int, opts ...ChanOption) Stream[T] {
+	if s.st == nil {
+		return s
+	}
+	ch, errc := Take(s.st.ctx, s.ch, n, opts...)
+	return then(s, ch, errc)
+}
+
+// Drop skips the first n values.
+func (s Stream[T]) Drop(n int, opts ...ChanOption) Stream[T] {
+	if s.st == nil {
+		return s
+	}
+	ch, errc := Drop(s.st.ctx, s.ch, n, opts...)
+	return then(s, ch, errc)
+}
+
+// Filter keeps values for which keep returns true.
+func (s Stream[T]) Filter(keep FilterFn[T], opts ...ChanOption) Stream[T] {
+	if s.st == nil {
+		return s
+	}
+	ch, errc := FilterChan(s.st.ctx, s.ch, keep, opts...)
+	return then(s, ch, errc)
+}
+
+// TakeWhile keeps values while pred returns true.
+func (s Stream[T]) TakeWhile(pred FilterFn[T], opts ...ChanOption) Stream[T] {
+	if s.st == nil {
+		return s
+	}
+	ch, errc := TakeWhile(s.st.ctx, s.ch, pred, opts...)
+	return then(s, ch, errc)
+}
+
+// DropWhile skips values while pred returns true.
+func (s Stream[T]) DropWhile(pred FilterFn[T], opts ...ChanOption) Stream[T] {
+	if s.st == nil {
+		return s
+	}
+	ch, errc := DropWhile(s.st.ctx, s.ch, pred, opts...)
+	return then(s, ch, errc)
+}
+
+// Buffer decouples the stream from its consumer with a buffer of n values.
+func (s Stream[T]) Buffer(n int) Stream[T] {
+	if s.st == nil {
+		return s
+	}
+	// Dropping zero values is a plain forwarding stage.
+	ch, errc := Drop(s.st.ctx, s.ch, 0, WithBuffer(n))
+	return then(s, ch, errc)
+}
+
+// Peek returns the first value and a Stream that still emits it.
+func (s Stream[T]) Peek(opts ...ChanOption) (Option[T], Stream[T]) {
+	if s.st == nil {
+		return Err[T](errNilStream), s
+	}
+	first, ch, errc := Peek(s.st.ctx, s.ch, opts...)
+	return first, then(s, ch, errc)
+}
+
+// Tee splits the stream into two branches. Both branches must be consumed
+// (concurrently, unless buffered) and each one reports the errors of the shared stages.
+func (s Stream[T]) Tee(opts ...ChanOption) (Stream[T], Stream[T]) {
+	if s.st == nil {
+		return s, s
+	}
+	a, b, errc := Tee(s.st.ctx, s.ch, opts...)
+	s.st.mu.Lock()
+	s.st.branches++
+	s.st.mu.Unlock()
+
+	stage := s.st.watch(errc)
+	stages := make([]*streamStage, len(s.stages), len(s.stages)+1)
+	copy(stages, s.stages)
+	stages = append(stages, stage)
+	return Stream[T]{st: s.st, ch: a, stages: stages}, Stream[T]{st: s.st, ch: b, stages: stages}
+}
+
+// Collect drains the stream into a slice.
+func (s Stream[T]) Collect() Option[[]T] {
+	if s.st == nil {
+		return Err[[]T](errNilStream)
+	}
+	out := Collect(s.st.ctx, s.ch)
+	if err := s.finish(); err != nil {
+		return Wrap(out.v, err)
+	}
+	if out.err != nil {
+		return Err[[]T](out.err)
+	}
+	return out
+}
+
+// Drain consumes the stream and returns the number of values seen.
+func (s Stream[T]) Drain() Option[int] {
+	return s.ForEach(nil)
+}
+
+// ForEach calls f for every value and returns the number of values seen.
+// A nil f just drains the stream.
+func (s Stream[T]) ForEach(f func(T)) Option[int] {
+	if s.st == nil {
+		return Err[int](errNilStream)
+	}
+	n := 0
+	var loopErr error
+loop:
+	for {
+		select {
+		case <-s.st.ctx.Done():
+			loopErr = s.st.ctx.Err()
+			break loop
+		case v, ok := <-s.ch:
+			if !ok {
+				break loop
+			}
+			if f != nil {
+				f(v)
+			}
+			n++
+		}
+	}
+	if err := s.finish(); err != nil {
+		return Wrap(n, err)
+	}
+	return Wrap(n, loopErr)
+}
+
+// Chan turns the stream back into a (channel, error channel) pair.
+// The error channel yields the joined error of all stages once the output is drained.
+func (s Stream[T]) Chan() (<-chan T, <-chan error) {
+	if s.st == nil {
+		return closedErrStream[T](errNilStream)
+	}
+	out := make(chan T)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		var loopErr error
+	loop:
+		for {
+			select {
+			case <-s.st.ctx.Done():
+				loopErr = s.st.ctx.Err()
+				break loop
+			case v, ok := <-s.ch:
+				if !ok {
+					break loop
+				}
+				select {
+				case <-s.st.ctx.Done():
+					loopErr = s.st.ctx.Err()
+					break loop
+				case out <- v:
+				}
+			}
+		}
+		if err := s.finish(); err != nil {
+			loopErr = err
+		}
+		errc <- loopErr
+	}()
+
+	return out, errc
+}
+
+// MapStream transforms every value of s using f.
+func MapStream[T, U any](s Stream[T], f MapFn[T, U]) Stream[U] {
+	if s.st == nil {
+		return Stream[U]{}
+	}
+	ch, errc := MapChan(s.st.ctx, s.ch, f)
+	return then(s, ch, errc)
+}
+
+// TryStream transforms every value of s using f. The first error fails the stream.
+func TryStream[T, U any](s Stream[T], f TryFn[T, U]) Stream[U] {
+	if s.st == nil {
+		return Stream[U]{}
+	}
+	// A single worker keeps the input order.
+	ch, errc := ParTryChan(s.st.ctx, s.ch, f, WithConcurrency(1))
+	return then(s, ch, errc)
+}
+
+// ParMapStream transforms values of s in parallel. Result order is not guaranteed.
+func ParMapStream[T, U any](s Stream[T], f MapFn[T, U], opts ...ParOption) Stream[U] {
+	if s.st == nil {
+		return Stream[U]{}
+	}
+	ch, errc := ParMapChan(s.st.ctx, s.ch, f, opts...)
+	return then(s, ch, errc)
+}
+
+// ParTryStream transforms values of s in parallel. Result order is not guaranteed.
+// The first error fails the stream.
+func ParTryStream[T, U any](s Stream[T], f TryFn[T, U], opts ...ParOption) Stream[U] {
+	if s.st == nil {
+		return Stream[U]{}
+	}
+	ch, errc := ParTryChan(s.st.ctx, s.ch, f, opts...)
+	return then(s, ch, errc)
+}
+
+// FlatMapStream expands every value of s using f.
+func FlatMapStream[T, U any](s Stream[T], f FlatMapFn[T, U], opts ...ChanOption) Stream[U] {
+	if s.st == nil {
+		return Stream[U]{}
+	}
+	ch, errc := FlatMapChan(s.st.ctx, s.ch, f, opts...)
+	return then(s, ch, errc)
+}
+
+// ScanStream emits every intermediate accumulator of folding s with f.
+func ScanStream[T, A any](s Stream[T], init A, f FoldFn[A, T], opts ...ChanOption) Stream[A] {
+	if s.st == nil {
+		return Stream[A]{}
+	}
+	ch, errc := ScanChan(s.st.ctx, s.ch, init, f, opts...)
+	return then(s, ch, errc)
+}
+
+// DistinctStream keeps only the first value seen for every key.
+func DistinctStream[T any, K comparable](s Stream[T], key KeyFn[T, K], opts ...ChanOption) Stream[T] {
+	if s.st == nil {
+		return s
+	}
+	ch, errc := DistinctChan(s.st.ctx, s.ch, key, opts...)
+	return then(s, ch, errc)
+}
+
+// ReduceStream folds s with f and returns the final accumulator.
+func ReduceStream[T, A any](s Stream[T], init A, f FoldFn[A, T]) Option[A] {
+	if s.st == nil {
+		return Err[A](errNilStream)
+	}
+	ch, errc := ReduceChan(s.st.ctx, s.ch, init, f)
+	got := then(s, ch, errc).Collect()
+	if got.err != nil {
+		return Err[A](got.err)
+	}
+	if len(got.v) == 0 {
+		return Err[A](io.EOF)
+	}
+	return Ok(got.v[0])
+}
@@ -0,0 +1,192 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitGoroutines waits until the number of goroutines drops to at most n.
+func waitGoroutines(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got := runtime.NumGoroutine()
+		if got <= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			buf = buf[:runtime.Stack(buf, true)]
+			t.Fatalf("goroutines=%d, want <= %d\n%s", got, n, buf)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStream_Chain(t *testing.T) {
+	t.Parallel()
+
+	got := StreamFrom(context.Background(), func(ctx context.Context) (<-chan int, <-chan error) {
+		return RangeN(ctx, 20)
+	}).
+		Drop(2).
+		Filter(func(v int) bool { return v%2 == 0 }).
+		Take(3).
+		Buffer(2).
+		Collect().
+		Must()
+
+	if want := []int{2, 4, 6}; len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	} else {
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		}
+	}
+}
+
+func TestStream_MapAndTry(t *testing.T) {
+	t.Parallel()
+
+	s := StreamOf(context.Background(), 1, 2, 3)
+	strs := MapStream(s, MapFn[int, string](strconv.Itoa))
+	back := TryStream(strs, TryFn[string, int](strconv.Atoi))
+	got := back.Collect().Must()
+	if len(got) != 3 || got[0] != 1 || got[2] != 3 {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestStream_ErrorCancelsSource(t *testing.T) {
+	// Not parallel: counts goroutines.
+	before := runtime.NumGoroutine()
+
+	sentinel := errors.New("boom")
+	s := StreamFrom(context.Background(), func(ctx context.Context) (<-chan int, <-chan error) {
+		return Repeat(ctx, 1)
+	})
+	failed := TryStream(s, TryFn[int, int](func(v int) (int, error) { return 0, sentinel }))
+	n := failed.Drain()
+	if !errors.Is(n.Err(), sentinel) {
+		t.Fatalf("err=%v, want %v", n.Err(), sentinel)
+	}
+	if errors.Is(n.Err(), context.Canceled) {
+		t.Fatalf("err=%v should not contain internal cancellation", n.Err())
+	}
+
+	waitGoroutines(t, before)
+}
+
+func TestStream_TakeFromInfiniteDoesNotLeak(t *testing.T) {
+	// Not parallel: counts goroutines.
+	before := runtime.NumGoroutine()
+
+	got := StreamFrom(context.Background(), func(ctx context.Context) (<-chan int, <-chan error) {
+		return Repeat(ctx, 7)
+	}).Take(5).Collect()
+	if v, err := got.Get(); err != nil || len(v) != 5 {
+		t.Fatalf("got (%v, %v)", v, err)
+	}
+
+	waitGoroutines(t, before)
+}
+
+func TestStream_ParentCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := StreamFrom(ctx, func(ctx context.Context) (<-chan int, <-chan error) {
+		return Repeat(ctx, 1)
+	}).Drain().Err()
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err=%v, want context.Canceled", err)
+	}
+}
+
+func TestStream_Peek(t *testing.T) {
+	t.Parallel()
+
+	first, rest := StreamOf(context.Background(), "a", "b").Peek()
+	if first.Must() != "a" {
+		t.Fatalf("first=%q", first.Must())
+	}
+	got := rest.Collect().Must()
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestStream_Tee(t *testing.T) {
+	t.Parallel()
+
+	a, b := StreamOf(context.Background(), 1, 2, 3).Tee()
+
+	var (
+		wg   sync.WaitGroup
+		gotB []int
+		errB error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		gotB, errB = b.Collect().Get()
+	}()
+	gotA := a.Collect().Must()
+	wg.Wait()
+	mustErr(t, errB)
+
+	sort.Ints(gotA)
+	sort.Ints(gotB)
+	if len(gotA) != 3 || len(gotB) != 3 || gotA[2] != 3 || gotB[2] != 3 {
+		t.Fatalf("got %v / %v", gotA, gotB)
+	}
+}
+
+func TestStream_ForEachAndChan(t *testing.T) {
+	t.Parallel()
+
+	sum := 0
+	n := StreamOf(context.Background(), 1, 2, 3).ForEach(func(v int) { sum += v }).Must()
+	if n != 3 || sum != 6 {
+		t.Fatalf("n=%d sum=%d", n, sum)
+	}
+
+	ch, errc := StreamOf(context.Background(), 4, 5).Chan()
+	got := Collect(context.Background(), ch).Must()
+	mustErr(t, <-errc)
+	if len(got) != 2 || got[0] != 4 {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestStream_NilSource(t *testing.T) {
+	t.Parallel()
+
+	err := StreamFrom[int](context.Background(), nil).Collect().Err()
+	if err == nil || err.Error() != ErrNilFunc("StreamFrom").Error() {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestStream_Operators(t *testing.T) {
+	t.Parallel()
+
+	s := StreamOf(context.Background(), "a b", "b c", "d").
+		TakeWhile(func(v string) bool { return v != "d" })
+	words := FlatMapStream(s, FlatMapFn[string, string](strings.Fields))
+	uniq := DistinctStream(words, KeyFn[string, string](func(v string) string { return v }))
+	got := ReduceStream(uniq, "", FoldFn[string, string](func(acc, v string) string { return acc + v })).Must()
+	if got != "abc" {
+		t.Fatalf("got %q", got)
+	}
+}
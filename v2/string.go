@@ -1,32 +1,40 @@
 package v2
 
 import (
-	"bufio"
+	"errors"
+	"io"
 	"strings"
 )
 
 // StringOp transforms a single line.
 type StringOp func(string) string
 
-// Lines splits a string into lines (scanner-based).
-func (s Str) Lines() Lines {
+// Lines splits a string into lines. Lines end in "\n" or "\r\n" and may be up to 1 MiB long;
+// WithMaxLineLength and WithLongLines configure the limit like for ReadLines.
+func (s Str) Lines(opts ...ChanOption) Lines {
 	if s.err != nil {
 		return Lines{Err[[]string](s.err)}
 	}
-
-	sc := bufio.NewScanner(strings.NewReader(s.v))
-	// Allow long lines (1MiB).
-	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
-
+	cfg, err := chanCfg(opts, featLines)
+	if err != nil {
+		return Lines{Err[[]string](err)}
+	}
+	sp := newLineSplitter(strings.NewReader(s.v), cfg)
 	lines := make([]string, 0)
-	for sc.Scan() {
-		lines = append(lines, sc.Text())
+	for {
+		l, err := sp.next()
+		if errors.Is(err, io.EOF) {
+			return Lines{Ok(lines)}
+		}
+		if err != nil {
+			return Lines{Wrap(lines, err)}
+		}
+		lines = append(lines, l.Text)
 	}
-	return Lines{Wrap(lines, sc.Err())}
 }
 
 // Lines splits bytes into lines by converting to string first.
-func (b Bytes) Lines() Lines { return b.String().Lines() }
+func (b Bytes) Lines(opts ...ChanOption) Lines { return b.String().Lines(opts...) }
 
 // ForEachLine applies fun to each line.
 func (l Lines) ForEachLine(fun StringOp) Lines {
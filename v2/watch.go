@@ -0,0 +1,217 @@
+package v2
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WatchOp is the kind of change reported by Watch.
+type WatchOp int
+
+const (
+	WatchCreated WatchOp = iota + 1
+	WatchModified
+	WatchRemoved
+)
+
+func (op WatchOp) String() string {
+	switch op {
+	case WatchCreated:
+		return "created"
+	case WatchModified:
+		return "modified"
+	case WatchRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// WatchEvent is a change detected by Watch. Info is nil for WatchRemoved.
+type WatchEvent struct {
+	Op   WatchOp
+	Path string
+	Info fs.FileInfo
+}
+
+// WithWatchFilter restricts the files Watch reports inside watched directories
+// (see WalkFilter; patterns are relative to the watched directory).
+func WithWatchFilter(f WalkFilter) ChanOption {
+	return func(c *chanConfig) {
+		if c == nil {
+			return
+		}
+		c.watchFilter = f
+		c.needs(featWatch, "WithWatchFilter")
+	}
+}
+
+// WithContentHash makes Watch hash file contents, so rewrites that keep size and
+// modification time are still reported as WatchModified. Every poll reads every file.
+func WithContentHash() ChanOption {
+	return func(c *chanConfig) {
+		if c == nil {
+			return
+		}
+		c.watchHash = true
+		c.needs(featWatch, "WithContentHash")
+	}
+}
+
+type fileState struct {
+	info fs.FileInfo
+	sum  [sha256.Size]byte
+}
+
+func (a fileState) changed(b fileState) bool {
+	return a.info.Size() != b.info.Size() || !a.info.ModTime().Equal(b.info.ModTime()) ||
+		a.info.Mode() != b.info.Mode() || a.sum != b.sum
+}
+
+// watchSnapshot stats every watched file; directories are walked recursively. The entries of a
+// directory that vanishes while it is walked are copied from prev, so a partial walk is not
+// reported as removals; the next poll sees the final state.
+func watchSnapshot(ctx context.Context, paths []string, cfg chanConfig, prev map[string]fileState) (map[string]fileState, error) {
+	snap := make(map[string]fileState)
+	add := func(path string, info fs.FileInfo) error {
+		st := fileState{info: info}
+		if cfg.watchHash && info.Mode().IsRegular() {
+			sum, err := Open(path).Stream().SHA256().Get()
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil // removed while polling; reported next time
+			}
+			if err != nil {
+				return err
+			}
+			st.sum = sum
+		}
+		snap[path] = st
+		return nil
+	}
+	for _, root := range paths {
+		info, err := os.Stat(root)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			if err := add(root, info); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		err = walkFS(ctx, os.DirFS(root), ".", cfg.watchFilter, func(e WalkEntry) error {
+			info, err := e.Info()
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return add(filepath.Join(root, filepath.FromSlash(e.Rel)), info)
+		}, func(rel string) {
+			dir := filepath.Join(root, filepath.FromSlash(rel))
+			for path, st := range prev {
+				if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+					snap[path] = st
+				}
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return snap, nil
+}
+
+// diffSnapshots returns the changes from old to cur, sorted by path.
+func diffSnapshots(old, cur map[string]fileState) []WatchEvent {
+	var events []WatchEvent
+	for path, st := range cur {
+		prev, ok := old[path]
+		switch {
+		case !ok:
+			events = append(events, WatchEvent{Op: WatchCreated, Path: path, Info: st.info})
+		case prev.changed(st):
+			events = append(events, WatchEvent{Op: WatchModified, Path: path, Info: st.info})
+		}
+	}
+	for path := range old {
+		if _, ok := cur[path]; !ok {
+			events = append(events, WatchEvent{Op: WatchRemoved, Path: path})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Path < events[j].Path })
+	return events
+}
+
+// Watch polls paths every interval and emits a WatchEvent for every file that was created,
+// modified or removed since the previous poll. Directories are watched recursively. Changes
+// are detected by diffing stat snapshots (size, modification time, mode and, with
+// WithContentHash, a SHA256 of the contents), so no platform notification API is needed.
+//
+// Files present at start produce no events. Paths that do not exist yet are watched for
+// creation. Polling uses the clock set with WithClock.
+func Watch(ctx context.Context, paths []string, interval time.Duration, opts ...ChanOption) (<-chan WatchEvent, <-chan error) {
+	if interval <= 0 {
+		return closedErrStream[WatchEvent](errInvalidInterval)
+	}
+	cfg, err := chanCfg(opts, featClock, featWatch)
+	if err != nil {
+		return closedErrStream[WatchEvent](err)
+	}
+	if err := cfg.watchFilter.validate(); err != nil {
+		return closedErrStream[WatchEvent](err)
+	}
+	paths = append([]string(nil), paths...)
+	out := make(chan WatchEvent, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Watch", errc)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		snap, err := watchSnapshot(ctx, paths, cfg, nil)
+		if err != nil {
+			errc <- err
+			return
+		}
+		t := cfg.clock.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case <-t.C():
+			}
+			cur, err := watchSnapshot(ctx, paths, cfg, snap)
+			if err != nil {
+				errc <- err
+				return
+			}
+			for _, ev := range diffSnapshots(snap, cur) {
+				select {
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				case out <- ev:
+				}
+			}
+			snap = cur
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
@@ -0,0 +1,124 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// nextEvents reads n events from out.
+func nextEvents(t *testing.T, out <-chan WatchEvent, n int) []WatchEvent {
+	t.Helper()
+	var got []WatchEvent
+	for len(got) < n {
+		select {
+		case ev, ok := <-out:
+			if !ok {
+				t.Fatalf("closed after %v", got)
+			}
+			got = append(got, ev)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out after %v", got)
+		}
+	}
+	return got
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatch_DetectsChanges(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	single := filepath.Join(t.TempDir(), "single.conf")
+	writeFile(t, filepath.Join(dir, "b.md"), "b")
+	writeFile(t, filepath.Join(dir, "c.md"), "c")
+
+	clock := newTestClock()
+	ctx, cancel := context.WithCancel(context.Background())
+	out, errc := Watch(ctx, []string{dir, single}, time.Second,
+		WithClock(clock), WithWatchFilter(WalkFilter{Exclude: []string{"**/*.tmp"}}))
+	waitTimers(t, clock, 1)
+
+	writeFile(t, filepath.Join(dir, "a.md"), "a")
+	writeFile(t, filepath.Join(dir, "ignored.tmp"), "x")
+	writeFile(t, filepath.Join(dir, "b.md"), "b, longer")
+	os.Remove(filepath.Join(dir, "c.md"))
+	os.MkdirAll(filepath.Join(dir, "sub"), 0o755)
+	writeFile(t, filepath.Join(dir, "sub", "d.md"), "d")
+	writeFile(t, single, "on")
+	clock.Advance(time.Second)
+
+	got := nextEvents(t, out, 5)
+	want := []struct {
+		op   WatchOp
+		path string
+	}{
+		{WatchCreated, filepath.Join(dir, "a.md")},
+		{WatchModified, filepath.Join(dir, "b.md")},
+		{WatchRemoved, filepath.Join(dir, "c.md")},
+		{WatchCreated, filepath.Join(dir, "sub", "d.md")},
+		{WatchCreated, single},
+	}
+	for i, w := range want {
+		if got[i].Op != w.op || got[i].Path != w.path {
+			t.Errorf("event %d = %v %s, want %v %s", i, got[i].Op, got[i].Path, w.op, w.path)
+		}
+	}
+	if got[2].Info != nil || got[0].Info.Size() != 1 {
+		t.Errorf("infos: %+v", got)
+	}
+
+	// A poll without changes emits nothing.
+	clock.Advance(time.Second)
+	os.Remove(single)
+	clock.Advance(time.Second)
+	if ev := nextEvents(t, out, 1)[0]; ev.Op != WatchRemoved || ev.Path != single {
+		t.Fatalf("got %v %s", ev.Op, ev.Path)
+	}
+
+	cancel()
+	Drain(nil, out)
+	if err := <-errc; err != context.Canceled {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestWatch_ContentHash(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeFile(t, path, "a: 1")
+	mtime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	os.Chtimes(path, mtime, mtime)
+
+	clock := newTestClock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out, _ := Watch(ctx, []string{dir}, time.Minute, WithClock(clock), WithContentHash())
+	waitTimers(t, clock, 1)
+
+	// Same size and modification time: only the hash tells the difference.
+	writeFile(t, path, "a: 2")
+	os.Chtimes(path, mtime, mtime)
+	clock.Advance(time.Minute)
+	if ev := nextEvents(t, out, 1)[0]; ev.Op != WatchModified || ev.Path != path {
+		t.Fatalf("got %v %s", ev.Op, ev.Path)
+	}
+
+	if _, errc := Watch(ctx, nil, 0); <-errc == nil {
+		t.Fatal("expected error for zero interval")
+	}
+	if _, errc := Walk(ctx, os.DirFS(dir), ".", WalkFilter{}, WithContentHash()); !errors.Is(<-errc, errUnsupportedOption) {
+		t.Fatal("expected errUnsupportedOption")
+	}
+}
@@ -0,0 +1,280 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	errInvalidBatchSize = errors.New("lambda/v2: batch size must be >= 1")
+	errInvalidWindow    = errors.New("lambda/v2: window durations must be > 0")
+)
+
+// AggFn aggregates the values of one window.
+type AggFn[T, A any] func([]T) A
+
+type windowKind int
+
+const (
+	windowTumbling windowKind = iota
+	windowSliding
+	windowSession
+)
+
+// WindowSpec describes how Window groups values. Use Tumbling, Sliding or Session.
+type WindowSpec struct {
+	kind  windowKind
+	size  time.Duration
+	slide time.Duration
+}
+
+// Tumbling groups values into consecutive, non-overlapping windows of length size.
+func Tumbling(size time.Duration) WindowSpec {
+	return WindowSpec{kind: windowTumbling, size: size, slide: size}
+}
+
+// Sliding groups values into windows of length size, emitted every slide.
+// A value belongs to every window that covers its arrival time.
+func Sliding(size, slide time.Duration) WindowSpec {
+	return WindowSpec{kind: windowSliding, size: size, slide: slide}
+}
+
+// Session groups values into windows separated by at least gap of inactivity.
+func Session(gap time.Duration) WindowSpec {
+	return WindowSpec{kind: windowSession, size: gap, slide: gap}
+}
+
+// WindowResult is the aggregate of one window.
+type WindowResult[A any] struct {
+	Start time.Time
+	End   time.Time
+	Count int
+	Value A
+}
+
+// Batch groups values from in into slices of up to size values.
+// A batch is flushed when it is full or when maxWait has passed since its first value
+// (maxWait <= 0 disables the timeout). A partial batch is flushed when in closes.
+func Batch[T any](ctx context.Context, in <-chan T, size int, maxWait time.Duration, opts ...ChanOption) (<-chan []T, <-chan error) {
+	if in == nil {
+		return closedErrStream[[]T](errNilChan)
+	}
+	if size < 1 {
+		return closedErrStream[[]T](errInvalidBatchSize)
+	}
+	cfg, err := chanCfg(opts, featClock)
+	if err != nil {
+		return closedErrStream[[]T](err)
+	}
+	out := make(chan []T, cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Batch", errc)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		var (
+			batch  []T
+			timer  Timer
+			timerC <-chan time.Time
+		)
+		flush := func() bool {
+			if timer != nil {
+				timer.Stop()
+				timer, timerC = nil, nil
+			}
+			if len(batch) == 0 {
+				return true
+			}
+			b := batch
+			batch = nil
+			select {
+			case <-ctx.Done():
+				return false
+			case out <- b:
+				return true
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case <-timerC:
+				timer, timerC = nil, nil
+				if !flush() {
+					errc <- ctx.Err()
+					return
+				}
+			case v, ok := <-in:
+				if !ok {
+					if !flush() {
+						errc <- ctx.Err()
+						return
+					}
+					errc <- nil
+					return
+				}
+				if batch == nil {
+					batch = make([]T, 0, size)
+				}
+				batch = append(batch, v)
+				if len(batch) == 1 && maxWait > 0 {
+					timer = cfg.clock.NewTimer(maxWait)
+					timerC = timer.C()
+				}
+				if len(batch) >= size && !flush() {
+					errc <- ctx.Err()
+					return
+				}
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+type stamped[T any] struct {
+	at time.Time
+	v  T
+}
+
+// Window groups values from in by arrival time (see Tumbling, Sliding and Session) and emits
+// agg of every non-empty window. Pending windows are flushed when in closes.
+func Window[T, A any](ctx context.Context, in <-chan T, spec WindowSpec, agg AggFn[T, A], opts ...ChanOption) (<-chan WindowResult[A], <-chan error) {
+	if in == nil {
+		return closedErrStream[WindowResult[A]](errNilChan)
+	}
+	if agg == nil {
+		return closedErrStream[WindowResult[A]](ErrNilFunc("Window"))
+	}
+	if spec.size <= 0 || spec.slide <= 0 {
+		return closedErrStream[WindowResult[A]](errInvalidWindow)
+	}
+	cfg, err := chanCfg(opts, featClock)
+	if err != nil {
+		return closedErrStream[WindowResult[A]](err)
+	}
+	out := make(chan WindowResult[A], cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "Window", errc)
+	in = meterIn(ctx, cfg.meter, in)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		clock := cfg.clock
+
+		var (
+			items  []stamped[T]
+			start  = clock.Now()
+			timer  Timer
+			timerC <-chan time.Time
+		)
+		arm := func(d time.Duration) {
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = clock.NewTimer(d)
+			timerC = timer.C()
+		}
+		emit := func(from, to time.Time, xs []stamped[T]) bool {
+			if len(xs) == 0 {
+				return true
+			}
+			vs := make([]T, len(xs))
+			for i := range xs {
+				vs[i] = xs[i].v
+			}
+			res := WindowResult[A]{Start: from, End: to, Count: len(vs), Value: agg(vs)}
+			select {
+			case <-ctx.Done():
+				return false
+			case out <- res:
+				return true
+			}
+		}
+		// evict drops values that fell out of a sliding window ending at now.
+		evict := func(now time.Time) {
+			cut := now.Add(-spec.size)
+			i := 0
+			for i < len(items) && !items[i].at.After(cut) {
+				i++
+			}
+			items = items[i:]
+		}
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+
+		if spec.kind != windowSession {
+			arm(spec.slide)
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+
+			case <-timerC:
+				timer, timerC = nil, nil
+				now := clock.Now()
+				var ok bool
+				switch spec.kind {
+				case windowTumbling:
+					end := start.Add(spec.size)
+					ok = emit(start, end, items)
+					items, start = nil, end
+					arm(spec.size)
+				case windowSliding:
+					evict(now)
+					ok = emit(now.Add(-spec.size), now, items)
+					arm(spec.slide)
+				case windowSession:
+					ok = emit(items[0].at, now, items)
+					items = nil
+				}
+				if !ok {
+					errc <- ctx.Err()
+					return
+				}
+
+			case v, ok := <-in:
+				now := clock.Now()
+				if !ok {
+					from := start
+					switch spec.kind {
+					case windowSliding:
+						evict(now)
+						from = now.Add(-spec.size)
+					case windowSession:
+						if len(items) > 0 {
+							from = items[0].at
+						}
+					}
+					if !emit(from, now, items) {
+						errc <- ctx.Err()
+						return
+					}
+					errc <- nil
+					return
+				}
+				items = append(items, stamped[T]{at: now, v: v})
+				if spec.kind == windowSession {
+					arm(spec.size)
+				}
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
@@ -0,0 +1,148 @@
+package v2
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBatch_Size(t *testing.T) {
+	t.Parallel()
+
+	src, srcErrc := RangeN(context.Background(), 7)
+	out, errc := Batch(context.Background(), src, 3, 0)
+	got := Collect(context.Background(), out).Must()
+	mustErr(t, JoinErr(srcErrc, errc))
+	if len(got) != 3 || !intsEqual(got[0], []int{0, 1, 2}) || !intsEqual(got[2], []int{6}) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestBatch_MaxWait(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	in := make(chan int)
+	out, errc := Batch(context.Background(), in, 10, time.Second, WithClock(clock))
+
+	in <- 1
+	in <- 2
+	waitTimers(t, clock, 1)
+	clock.Advance(time.Second)
+	if got := <-out; !intsEqual(got, []int{1, 2}) {
+		t.Fatalf("got %v, want [1 2]", got)
+	}
+
+	in <- 3
+	close(in)
+	if got := <-out; !intsEqual(got, []int{3}) {
+		t.Fatalf("got %v, want [3]", got)
+	}
+	mustErr(t, <-errc)
+}
+
+func sumInts(xs []int) int {
+	s := 0
+	for _, x := range xs {
+		s += x
+	}
+	return s
+}
+
+func TestWindow_Tumbling(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	start := clock.Now()
+	in := make(chan int)
+	out, errc := Window(context.Background(), in, Tumbling(time.Minute), AggFn[int, int](sumInts), WithClock(clock))
+
+	waitTimers(t, clock, 1)
+	in <- 1
+	in <- 2
+	clock.Advance(time.Minute)
+	res := <-out
+	if res.Value != 3 || res.Count != 2 || !res.Start.Equal(start) || !res.End.Equal(start.Add(time.Minute)) {
+		t.Fatalf("got %+v", res)
+	}
+
+	waitTimers(t, clock, 2)
+	in <- 10
+	close(in)
+	if res := <-out; res.Value != 10 || res.Count != 1 {
+		t.Fatalf("got %+v", res)
+	}
+	mustErr(t, <-errc)
+}
+
+func TestWindow_Sliding(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	in := make(chan int)
+	out, errc := Window(context.Background(), in, Sliding(2*time.Minute, time.Minute), AggFn[int, int](sumInts), WithClock(clock))
+
+	waitTimers(t, clock, 1)
+	clock.Advance(30 * time.Second)
+	in <- 1
+	clock.Advance(30 * time.Second)
+	if res := <-out; res.Value != 1 {
+		t.Fatalf("got %+v, want 1", res)
+	}
+
+	waitTimers(t, clock, 2)
+	clock.Advance(30 * time.Second)
+	in <- 2
+	clock.Advance(30 * time.Second)
+	if res := <-out; res.Value != 3 || res.Count != 2 {
+		t.Fatalf("got %+v, want 3", res)
+	}
+
+	// The first value has now left the window.
+	waitTimers(t, clock, 3)
+	clock.Advance(time.Minute)
+	if res := <-out; res.Value != 2 {
+		t.Fatalf("got %+v, want 2", res)
+	}
+
+	close(in)
+	for range out {
+	}
+	mustErr(t, <-errc)
+}
+
+func TestWindow_Session(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	in := make(chan int)
+	out, errc := Window(context.Background(), in, Session(time.Second), AggFn[int, int](sumInts), WithClock(clock))
+
+	in <- 1
+	waitTimers(t, clock, 1)
+	clock.Advance(500 * time.Millisecond)
+	in <- 2
+	waitTimers(t, clock, 2)
+	clock.Advance(time.Second)
+	if res := <-out; res.Value != 3 || res.Count != 2 {
+		t.Fatalf("got %+v", res)
+	}
+
+	in <- 5
+	close(in)
+	if res := <-out; res.Value != 5 {
+		t.Fatalf("got %+v", res)
+	}
+	mustErr(t, <-errc)
+}
+
+func TestWindow_InvalidSpec(t *testing.T) {
+	t.Parallel()
+
+	in := make(chan int)
+	out, errc := Window(context.Background(), in, Tumbling(0), AggFn[int, int](sumInts))
+	_ = Collect(context.Background(), out).Must()
+	if err := <-errc; err != errInvalidWindow {
+		t.Fatalf("err=%v", err)
+	}
+}
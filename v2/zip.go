@@ -0,0 +1,358 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var errInvalidBound = errors.New("lambda/v2: join bounds must be >= 0")
+
+// Pair holds two values.
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// PairOf constructs a Pair.
+func PairOf[A, B any](a A, b B) Pair[A, B] { return Pair[A, B]{First: a, Second: b} }
+
+// Joined is a result of JoinChan. Matched results have both sides set;
+// unmatched leftovers have only one side set.
+type Joined[K comparable, L, R any] struct {
+	Key      K
+	Left     L
+	Right    R
+	HasLeft  bool
+	HasRight bool
+}
+
+// Matched reports whether both sides are set.
+func (j Joined[K, L, R]) Matched() bool { return j.HasLeft && j.HasRight }
+
+// WithMaxAge bounds how long JoinChan keeps an unmatched value before emitting it as a leftover.
+// 0 (default) keeps values until both inputs close.
+func WithMaxAge(d time.Duration) ChanOption {
+	return func(c *chanConfig) {
+		if c == nil {
+			return
+		}
+		c.maxAge = d
+		c.needs(featJoin, "WithMaxAge")
+	}
+}
+
+// WithMaxPending bounds how many unmatched values JoinChan keeps; the oldest are emitted as
+// leftovers first. 0 (default) means unbounded.
+func WithMaxPending(n int) ChanOption {
+	return func(c *chanConfig) {
+		if c == nil {
+			return
+		}
+		c.maxPending = n
+		c.needs(featJoin, "WithMaxPending")
+	}
+}
+
+// ZipChan pairs the i-th value of a with the i-th value of b.
+// The output closes as soon as either input closes, even if the other one is still open.
+func ZipChan[A, B any](ctx context.Context, a <-chan A, b <-chan B, opts ...ChanOption) (<-chan Pair[A, B], <-chan error) {
+	if a == nil || b == nil {
+		return closedErrStream[Pair[A, B]](errNilChan)
+	}
+	cfg, err := chanCfg(opts)
+	if err != nil {
+		return closedErrStream[Pair[A, B]](err)
+	}
+	out := make(chan Pair[A, B], cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "ZipChan", errc)
+	a = meterIn(ctx, cfg.meter, a)
+	b = meterIn(ctx, cfg.meter, b)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		for {
+			// Read both sides in whatever order they arrive, so a closed input is noticed
+			// even while the other one is idle.
+			var p Pair[A, B]
+			ina, inb := a, b
+			for ina != nil || inb != nil {
+				select {
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				case v, ok := <-ina:
+					if !ok {
+						errc <- nil
+						return
+					}
+					p.First, ina = v, nil
+				case v, ok := <-inb:
+					if !ok {
+						errc <- nil
+						return
+					}
+					p.Second, inb = v, nil
+				}
+			}
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case out <- p:
+			}
+		}
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+// CombineLatest emits the latest values of a and b whenever either changes,
+// once both have produced a value. The output closes once both inputs close.
+func CombineLatest[A, B any](ctx context.Context, a <-chan A, b <-chan B, opts ...ChanOption) (<-chan Pair[A, B], <-chan error) {
+	if a == nil || b == nil {
+		return closedErrStream[Pair[A, B]](errNilChan)
+	}
+	cfg, err := chanCfg(opts)
+	if err != nil {
+		return closedErrStream[Pair[A, B]](err)
+	}
+	out := make(chan Pair[A, B], cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "CombineLatest", errc)
+	a = meterIn(ctx, cfg.meter, a)
+	b = meterIn(ctx, cfg.meter, b)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		var (
+			latest     Pair[A, B]
+			hasA, hasB bool
+			ac, bc     = a, b
+		)
+		for ac != nil || bc != nil {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case v, ok := <-ac:
+				if !ok {
+					ac = nil
+					continue
+				}
+				latest.First, hasA = v, true
+			case v, ok := <-bc:
+				if !ok {
+					bc = nil
+					continue
+				}
+				latest.Second, hasB = v, true
+			}
+			if !hasA || !hasB {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case out <- latest:
+			}
+		}
+		errc <- nil
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
+
+type joinEntry[K comparable, L, R any] struct {
+	j    Joined[K, L, R]
+	at   time.Time
+	done bool
+}
+
+// JoinChan matches values of left and right by key (one-to-one, oldest first).
+//
+// Unmatched values are buffered until a partner arrives. WithMaxAge and WithMaxPending bound
+// that buffer; values evicted by either bound, and values still unmatched once both inputs
+// close, are emitted as leftovers (see Joined.Matched).
+func JoinChan[K comparable, L, R any](ctx context.Context, left <-chan L, right <-chan R, lkey KeyFn[L, K], rkey KeyFn[R, K], opts ...ChanOption) (<-chan Joined[K, L, R], <-chan error) {
+	if left == nil || right == nil {
+		return closedErrStream[Joined[K, L, R]](errNilChan)
+	}
+	if lkey == nil || rkey == nil {
+		return closedErrStream[Joined[K, L, R]](ErrNilFunc("JoinChan"))
+	}
+	cfg, err := chanCfg(opts, featClock, featJoin)
+	if err != nil {
+		return closedErrStream[Joined[K, L, R]](err)
+	}
+	if cfg.maxAge < 0 || cfg.maxPending < 0 {
+		return closedErrStream[Joined[K, L, R]](errInvalidBound)
+	}
+	out := make(chan Joined[K, L, R], cfg.buffer)
+	errc := make(chan error, 1)
+	ctx, tracked := cfg.attach(ctx, "JoinChan", errc)
+	left = meterIn(ctx, cfg.meter, left)
+	right = meterIn(ctx, cfg.meter, right)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		ctx = ensureCtx(ctx)
+		clock := cfg.clock
+
+		type entry = joinEntry[K, L, R]
+		var (
+			pendL   = make(map[K][]*entry)
+			pendR   = make(map[K][]*entry)
+			order   []*entry
+			pending int
+
+			timer    Timer
+			timerC   <-chan time.Time
+			armedFor time.Time
+
+			lc, rc = left, right
+		)
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+
+		send := func(j Joined[K, L, R]) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case out <- j:
+				return true
+			}
+		}
+		oldest := func() *entry {
+			for len(order) > 0 && order[0].done {
+				order = order[1:]
+			}
+			if len(order) == 0 {
+				return nil
+			}
+			return order[0]
+		}
+		// evictOldest emits the oldest unmatched value as a leftover.
+		evictOldest := func() bool {
+			e := oldest()
+			if e == nil {
+				return true
+			}
+			e.done = true
+			pending--
+			pend := pendR
+			if e.j.HasLeft {
+				pend = pendL
+			}
+			if q := pend[e.j.Key][1:]; len(q) > 0 {
+				pend[e.j.Key] = q
+			} else {
+				delete(pend, e.j.Key)
+			}
+			return send(e.j)
+		}
+		// enforce applies both bounds and re-arms the age timer.
+		enforce := func() bool {
+			for cfg.maxPending > 0 && pending > cfg.maxPending {
+				if !evictOldest() {
+					return false
+				}
+			}
+			if cfg.maxAge <= 0 {
+				return true
+			}
+			now := clock.Now()
+			for e := oldest(); e != nil && !e.at.Add(cfg.maxAge).After(now); e = oldest() {
+				if !evictOldest() {
+					return false
+				}
+			}
+			e := oldest()
+			if e == nil {
+				return true
+			}
+			if deadline := e.at.Add(cfg.maxAge); timer == nil || !deadline.Equal(armedFor) {
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = clock.NewTimer(deadline.Sub(now))
+				timerC, armedFor = timer.C(), deadline
+			}
+			return true
+		}
+		// add matches j against the other side or buffers it.
+		add := func(j Joined[K, L, R], own, other map[K][]*entry) bool {
+			if q := other[j.Key]; len(q) > 0 {
+				partner := q[0]
+				if len(q) > 1 {
+					other[j.Key] = q[1:]
+				} else {
+					delete(other, j.Key)
+				}
+				partner.done = true
+				pending--
+				if j.HasLeft {
+					j.Right, j.HasRight = partner.j.Right, true
+				} else {
+					j.Left, j.HasLeft = partner.j.Left, true
+				}
+				return send(j)
+			}
+			e := &entry{j: j, at: clock.Now()}
+			own[j.Key] = append(own[j.Key], e)
+			order = append(order, e)
+			pending++
+			return true
+		}
+
+		for lc != nil || rc != nil {
+			ok := true
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case <-timerC:
+				timer, timerC = nil, nil
+			case v, more := <-lc:
+				if !more {
+					lc = nil
+					continue
+				}
+				ok = add(Joined[K, L, R]{Key: lkey(v), Left: v, HasLeft: true}, pendL, pendR)
+			case v, more := <-rc:
+				if !more {
+					rc = nil
+					continue
+				}
+				ok = add(Joined[K, L, R]{Key: rkey(v), Right: v, HasRight: true}, pendR, pendL)
+			}
+			if !ok || !enforce() {
+				errc <- ctx.Err()
+				return
+			}
+		}
+
+		for e := oldest(); e != nil; e = oldest() {
+			if !evictOldest() {
+				errc <- ctx.Err()
+				return
+			}
+		}
+		errc <- nil
+	}()
+
+	return meterOut(cfg.meter, out), tracked
+}
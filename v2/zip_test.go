@@ -0,0 +1,189 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestZipChan(t *testing.T) {
+	t.Parallel()
+
+	a, aErrc := RangeN(context.Background(), 3)
+	b, bErrc := FromSlice(context.Background(), []string{"x", "y", "z", "extra"})
+	out, errc := ZipChan(context.Background(), a, b)
+	got := Collect(context.Background(), out).Must()
+	mustErr(t, <-errc)
+	mustErr(t, <-aErrc)
+	// b may still be blocked on "extra"; drain it.
+	_ = Drain(context.Background(), b)
+	mustErr(t, <-bErrc)
+
+	if len(got) != 3 {
+		t.Fatalf("got %v", got)
+	}
+	for i, p := range got {
+		if p.First != i || p.Second != string(rune('x'+i)) {
+			t.Fatalf("got[%d]=%+v", i, p)
+		}
+	}
+}
+
+func TestZipChan_StopsWhenEitherCloses(t *testing.T) {
+	t.Parallel()
+
+	a := make(chan int) // stays open and idle
+	b := make(chan string)
+	close(b)
+	out, errc := ZipChan(context.Background(), a, b)
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Fatal("unexpected value")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ZipChan blocked on the open input")
+	}
+	mustErr(t, <-errc)
+}
+
+func TestCombineLatest(t *testing.T) {
+	t.Parallel()
+
+	a := make(chan int)
+	b := make(chan string)
+	out, errc := CombineLatest(context.Background(), a, b)
+
+	a <- 1
+	a <- 2
+	b <- "x"
+	if p := <-out; p != PairOf(2, "x") {
+		t.Fatalf("got %+v", p)
+	}
+	b <- "y"
+	if p := <-out; p != PairOf(2, "y") {
+		t.Fatalf("got %+v", p)
+	}
+	close(b)
+	a <- 3
+	if p := <-out; p != PairOf(3, "y") {
+		t.Fatalf("got %+v", p)
+	}
+	close(a)
+	if _, ok := <-out; ok {
+		t.Fatalf("expected closed output")
+	}
+	mustErr(t, <-errc)
+}
+
+type request struct {
+	ID   int
+	Path string
+}
+
+type response struct {
+	ID     int
+	Status int
+}
+
+func TestJoinChan_MatchesAndLeftovers(t *testing.T) {
+	t.Parallel()
+
+	reqs, reqErrc := FromSlice(context.Background(), []request{{1, "/a"}, {2, "/b"}, {3, "/c"}})
+	resps, respErrc := FromSlice(context.Background(), []response{{2, 200}, {1, 404}, {9, 500}})
+	out, errc := JoinChan(context.Background(), reqs, resps,
+		KeyFn[request, int](func(r request) int { return r.ID }),
+		KeyFn[response, int](func(r response) int { return r.ID }))
+	got := Collect(context.Background(), out).Must()
+	mustErr(t, JoinErr(reqErrc, respErrc, errc))
+
+	var matched, leftovers []string
+	for _, j := range got {
+		if j.Matched() {
+			matched = append(matched, j.Left.Path+"="+strconv.Itoa(j.Right.Status))
+		} else {
+			leftovers = append(leftovers, strconv.Itoa(j.Key))
+		}
+	}
+	sort.Strings(matched)
+	sort.Strings(leftovers)
+	if len(matched) != 2 || matched[0] != "/a=404" || matched[1] != "/b=200" {
+		t.Fatalf("matched=%v", matched)
+	}
+	if len(leftovers) != 2 || leftovers[0] != "3" || leftovers[1] != "9" {
+		t.Fatalf("leftovers=%v", leftovers)
+	}
+}
+
+func TestJoinChan_MaxPending(t *testing.T) {
+	t.Parallel()
+
+	left := make(chan int)
+	right := make(chan int)
+	id := KeyFn[int, int](func(v int) int { return v })
+	out, errc := JoinChan(context.Background(), left, right, id, id, WithMaxPending(1))
+
+	left <- 1
+	left <- 2 // evicts 1
+	j := <-out
+	if j.Matched() || !j.HasLeft || j.Left != 1 {
+		t.Fatalf("got %+v, want leftover 1", j)
+	}
+	right <- 1 // 1 is gone, so this evicts 2
+	if j := <-out; j.Matched() || j.Left != 2 {
+		t.Fatalf("got %+v, want leftover 2", j)
+	}
+	close(left)
+	close(right)
+	if j := <-out; j.Matched() || !j.HasRight || j.Right != 1 {
+		t.Fatalf("got %+v, want leftover right 1", j)
+	}
+	mustErr(t, <-errc)
+}
+
+func TestJoinChan_MaxAge(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	left := make(chan int)
+	right := make(chan int)
+	id := KeyFn[int, int](func(v int) int { return v })
+	out, errc := JoinChan(context.Background(), left, right, id, id, WithMaxAge(time.Second), WithClock(clock))
+
+	left <- 1
+	waitTimers(t, clock, 1)
+	clock.Advance(time.Second)
+	if j := <-out; j.Matched() || j.Left != 1 {
+		t.Fatalf("got %+v, want leftover 1", j)
+	}
+
+	left <- 2
+	right <- 2
+	if j := <-out; !j.Matched() || j.Key != 2 {
+		t.Fatalf("got %+v, want match 2", j)
+	}
+	close(left)
+	close(right)
+	for range out {
+	}
+	mustErr(t, <-errc)
+}
+
+func TestJoinOptions_RejectedElsewhere(t *testing.T) {
+	t.Parallel()
+
+	a := make(chan int)
+	b := make(chan int)
+	_, errc := ZipChan(context.Background(), a, b, WithMaxPending(1))
+	if err := <-errc; !errors.Is(err, errUnsupportedOption) || !strings.Contains(err.Error(), "WithMaxPending") {
+		t.Fatalf("err = %v", err)
+	}
+	_, errc = CombineLatest(context.Background(), a, b, WithMaxAge(time.Second))
+	if err := <-errc; !errors.Is(err, errUnsupportedOption) {
+		t.Fatalf("err = %v", err)
+	}
+}